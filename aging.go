@@ -0,0 +1,87 @@
+package hgtealib
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// AgingTargets is the age, in years, at which a tea of the given type is considered ready
+var AgingTargets = map[string]float64{}
+
+// DefaultAgingTarget is used for aging teas whose type has no target; zero means no target
+var DefaultAgingTarget float64
+
+const yearDuration = 365.25 * 24 * time.Hour
+
+// AgeAt returns the age of the tea in years at the given time, based on the year it was picked or, failing that,
+// the date it was purchased
+func (t *Tea) AgeAt(at time.Time) (float64, bool) {
+	var born time.Time
+	switch {
+	case t.Picked.Year > 0:
+		born = time.Date(t.Picked.Year, time.January, 1, 0, 0, 0, 0, at.Location())
+	case !t.Purchased.Date.IsZero():
+		born = t.Purchased.Date
+	default:
+		return 0, false
+	}
+
+	age := at.Sub(born).Hours() / yearDuration.Hours()
+	if age < 0 {
+		age = 0
+	}
+	return age, true
+}
+
+func (t *Tea) AgingTarget() float64 {
+	for _, ancestor := range TeaTypes.Ancestors(t.Type) {
+		for teaType, target := range AgingTargets {
+			if strings.EqualFold(TeaTypes.Canonical(teaType), ancestor) {
+				return target
+			}
+		}
+	}
+	return DefaultAgingTarget
+}
+
+// ReachedAgingTarget returns true if the tea is aging and is at least as old as its aging target
+func (t *Tea) ReachedAgingTarget(now time.Time) bool {
+	target := t.AgingTarget()
+	if !t.Storage.Aging || target <= 0 {
+		return false
+	}
+
+	age, ok := t.AgeAt(now)
+	return ok && age >= target
+}
+
+type AgeRating struct {
+	Age     int
+	Entries int
+	Average float64
+}
+
+// RatingsByAge returns the average rating of the tea for each year of age at which it was tasted
+func (t *Tea) RatingsByAge() []AgeRating {
+	totals := make(map[int]int)
+	counts := make(map[int]int)
+	for _, entry := range t.log {
+		age, ok := t.AgeAt(entry.DateTime)
+		if !ok {
+			continue
+		}
+		totals[int(age)] += entry.Rating
+		counts[int(age)]++
+	}
+
+	ratings := make([]AgeRating, 0)
+	for age, count := range counts {
+		ratings = append(ratings, AgeRating{Age: age, Entries: count, Average: float64(totals[age]) / float64(count)})
+	}
+	sort.Slice(ratings, func(i, j int) bool {
+		return ratings[i].Age < ratings[j].Age
+	})
+
+	return ratings
+}
@@ -0,0 +1,81 @@
+package hgtealib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTeaAgeAt(t *testing.T) {
+	at := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tea := Tea{Picked: TeaPickPeriod{Year: 2010}}
+	if age, ok := tea.AgeAt(at); !ok || int(age+0.01) != 10 {
+		t.Errorf("Expected age of 10 years but found %f", age)
+	}
+
+	tea = Tea{Purchased: TeaPurchaseInfo{Date: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)}}
+	if age, ok := tea.AgeAt(at); !ok || int(age+0.01) != 2 {
+		t.Errorf("Expected age of 2 years from the purchase date but found %f", age)
+	}
+
+	if _, ok := (&Tea{}).AgeAt(at); ok {
+		t.Error("Found an age for a tea without a year or purchase date")
+	}
+}
+
+func TestTeaReachedAgingTarget(t *testing.T) {
+	defer func(targets map[string]float64, fallback float64) {
+		AgingTargets = targets
+		DefaultAgingTarget = fallback
+	}(AgingTargets, DefaultAgingTarget)
+
+	AgingTargets = map[string]float64{"Sheng": 10}
+	DefaultAgingTarget = 0
+
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	tea := Tea{Type: "sheng", Picked: TeaPickPeriod{Year: 2009}, Storage: TeaStorageState{Aging: true}}
+
+	if !tea.ReachedAgingTarget(now) {
+		t.Error("Tea did not reach its aging target")
+	}
+
+	tea.Picked.Year = 2015
+	if tea.ReachedAgingTarget(now) {
+		t.Error("Tea reached its aging target too early")
+	}
+
+	tea = Tea{Type: "Black", Picked: TeaPickPeriod{Year: 1990}, Storage: TeaStorageState{Aging: true}}
+	if tea.ReachedAgingTarget(now) {
+		t.Error("Tea without an aging target reached it")
+	}
+
+	DefaultAgingTarget = 20
+	if !tea.ReachedAgingTarget(now) {
+		t.Error("Tea did not reach the default aging target")
+	}
+
+	tea.Storage.Aging = false
+	if tea.ReachedAgingTarget(now) {
+		t.Error("Tea that is not aging reached its aging target")
+	}
+}
+
+func TestTeaRatingsByAge(t *testing.T) {
+	tea := Tea{Picked: TeaPickPeriod{Year: 2010}}
+	tea.Add(Entry{DateTime: time.Date(2010, 6, 1, 0, 0, 0, 0, time.UTC), Rating: 1})
+	tea.Add(Entry{DateTime: time.Date(2010, 8, 1, 0, 0, 0, 0, time.UTC), Rating: 2})
+	tea.Add(Entry{DateTime: time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC), Rating: 4})
+
+	ratings := tea.RatingsByAge()
+	if len(ratings) != 2 {
+		t.Fatalf("Expected 2 ages but found %d: %v", len(ratings), ratings)
+	}
+
+	if ratings[0].Age != 0 || ratings[0].Entries != 2 || ratings[0].Average != 1.5 {
+		t.Errorf("Unexpected ratings at age 0: %+v", ratings[0])
+	}
+
+	if ratings[1].Age != 5 || ratings[1].Entries != 1 || ratings[1].Average != 4 {
+		t.Errorf("Unexpected ratings at age 5: %+v", ratings[1])
+	}
+}
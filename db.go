@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -11,7 +12,12 @@ import (
 type Filter struct {
 	stockedOnly bool
 	samplesOnly bool
+	agingOnly   bool
 	types       map[string]struct{}
+	ratings     map[string]int
+	grades      []string
+	minRatio    float64
+	maxRatio    float64
 }
 
 func (f *Filter) StockedOnly() *Filter {
@@ -24,6 +30,11 @@ func (f *Filter) SamplesOnly() *Filter {
 	return f
 }
 
+func (f *Filter) AgingOnly() *Filter {
+	f.agingOnly = true
+	return f
+}
+
 func (f *Filter) Types(v []string) *Filter {
 	if len(v) > 0 {
 		for _, t := range v {
@@ -40,12 +51,84 @@ func (f *Filter) Type(v string) *Filter {
 	return f
 }
 
+// Grade only selects teas whose leaf grade has the given code, style or part, such as "FTGFOP1", "broken" or "tippy"
+func (f *Filter) Grade(v string) *Filter {
+	f.grades = append(f.grades, v)
+	return f
+}
+
+func (f *Filter) Grades(v []string) *Filter {
+	for _, g := range v {
+		if g != "" {
+			f.Grade(g)
+		}
+	}
+	return f
+}
+
+// MinRating only selects teas with a product rating of at least min in the given dimension
+func (f *Filter) MinRating(dimension string, min int) *Filter {
+	if name, ok := productRatingName(dimension); ok {
+		f.ratings[name] = min
+	} else {
+		f.ratings[dimension] = min
+	}
+	return f
+}
+
+func (f *Filter) matchesType(tea Tea) bool {
+	for t := range f.types {
+		if TeaTypes.IsA(tea.Type, t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) matchesGrade(tea Tea) bool {
+	for _, g := range f.grades {
+		if tea.LeafGrade.Is(g) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) matchesRatings(tea Tea) bool {
+	for name, min := range f.ratings {
+		if rating, ok := tea.Ratings[name]; !ok || rating < min {
+			return false
+		}
+	}
+	return true
+}
+
+// Ratio only selects log entries which used between min and max grams of leaf per 100ml of water.
+// A max of zero means there is no upper limit.
+func (f *Filter) Ratio(min, max float64) *Filter {
+	f.minRatio = min
+	f.maxRatio = max
+	return f
+}
+
+func (f *Filter) matchesEntry(entry Entry) bool {
+	if f.minRatio > 0 || f.maxRatio > 0 {
+		ratio := entry.Ratio()
+		if ratio == 0 || ratio < f.minRatio || (f.maxRatio > 0 && ratio > f.maxRatio) {
+			return false
+		}
+	}
+	return true
+}
+
 func NewFilter() *Filter {
 	f := new(Filter)
 
 	f.stockedOnly = false
 	f.samplesOnly = false
+	f.agingOnly = false
 	f.types = make(map[string]struct{})
+	f.ratings = make(map[string]int)
 
 	return f
 }
@@ -54,27 +137,46 @@ type TeaDb struct {
 	teas          map[int]Tea
 	log           map[time.Time]Entry
 	logSortedKeys TimeSlice
+	index         *SearchIndex
+	store         TeaStore
+	warnings      []error
+}
+
+// Warnings returns the problems with the data of the database which did not keep it from loading
+func (d *TeaDb) Warnings() []error {
+	return d.warnings
+}
+
+func (f *Filter) matches(tea Tea) bool {
+	if f.stockedOnly && !tea.Storage.Stocked {
+		return false
+	}
+
+	if f.agingOnly && !tea.Storage.Aging {
+		return false
+	}
+
+	// if f.samplesOnly && !strings.Contains(strings.ToLower(tea.Size), "sample") {
+	// return false
+	// }
+
+	if len(f.types) > 0 && !f.matchesType(tea) {
+		return false
+	}
+
+	if len(f.grades) > 0 && !f.matchesGrade(tea) {
+		return false
+	}
+
+	return f.matchesRatings(tea)
 }
 
 func (d *TeaDb) Teas(filter *Filter) (map[int]Tea, error) {
 	teas := make(map[int]Tea)
 	for k, v := range d.teas {
-		// Now apply the filters
-		if filter.stockedOnly && !v.Storage.Stocked {
-			continue
+		if filter.matches(v) {
+			teas[k] = v
 		}
-
-		// if filter.samplesOnly && !strings.Contains(strings.ToLower(v.Size), "sample") {
-		// continue
-		// }
-
-		if len(filter.types) > 0 {
-			if _, ok := filter.types[strings.ToLower(v.Type)]; !ok {
-				continue
-			}
-		}
-
-		teas[k] = v
 	}
 	return teas, nil
 }
@@ -86,16 +188,94 @@ func (d *TeaDb) Tea(id int) (Tea, error) {
 	return *new(Tea), errors.New(fmt.Sprintf("Could not retrieve Tea by id: %d", id))
 }
 
+// TeaByName returns the tea which best matches the given name, as described by resolveTea. An AmbiguousTeaError
+// listing the candidates is returned when more than one tea matches.
+func (d *TeaDb) TeaByName(name string) (Tea, error) {
+	return resolveTea(d.teas, name)
+}
+
+// FindTea returns the tea with the given id, or else the tea which best matches the given name
+func (d *TeaDb) FindTea(idOrName string) (Tea, error) {
+	if id, err := strconv.Atoi(strings.TrimSpace(idOrName)); err == nil {
+		if tea, ok := d.teas[id]; ok {
+			return tea, nil
+		}
+	}
+	return d.TeaByName(idOrName)
+}
+
 func (d *TeaDb) Log(filter *Filter) ([]Entry, error) {
 	log := make([]Entry, 0)
 	for _, k := range d.logSortedKeys {
-		log = append(log, d.log[k])
+		if filter.matchesEntry(d.log[k]) {
+			log = append(log, d.log[k])
+		}
 	}
 	return log, nil
 }
 
+// TeasLog returns the entries of the log whose teas also match the filter, unlike Log which only applies the
+// filters of entries
+func (d *TeaDb) TeasLog(filter *Filter) ([]Entry, error) {
+	log, err := d.Log(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]Entry, 0, len(log))
+	for _, entry := range log {
+		if filter.matches(d.teas[entry.Tea]) {
+			filtered = append(filtered, entry)
+		}
+	}
+	return filtered, nil
+}
+
+// Consumption returns the number of entries logged for each tea, with the entries of
+// a blend credited to its component teas according to the blend ratio
+func (d *TeaDb) Consumption() map[int]float64 {
+	consumption := make(map[int]float64)
+	for _, entry := range d.log {
+		d.credit(consumption, entry.Tea, 1)
+	}
+	return consumption
+}
+
+func (d *TeaDb) credit(consumption map[int]float64, id int, amount float64) {
+	tea, ok := d.teas[id]
+	if !ok || !tea.IsBlend() {
+		consumption[id] += amount
+		return
+	}
+
+	for component, share := range tea.Blend.Shares() {
+		d.credit(consumption, component, amount*share)
+	}
+}
+
+func (d *TeaDb) validateBlend(tea Tea, seen map[int]struct{}) error {
+	if _, ok := seen[tea.Id]; ok {
+		return errors.New(fmt.Sprintf("Tea %d is blended with itself", tea.Id))
+	}
+	seen[tea.Id] = struct{}{}
+	defer delete(seen, tea.Id)
+
+	for _, c := range tea.Blend {
+		component, ok := d.teas[c.Tea]
+		if !ok {
+			return errors.New(fmt.Sprintf("Blend %d references unknown tea: %d", tea.Id, c.Tea))
+		}
+		if err := d.validateBlend(component, seen); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func newTeaDb(teas []*Tea, entries []*Entry) (*TeaDb, error) {
 	db := new(TeaDb)
+	db.store = memoryStore{}
 	db.teas = make(map[int]Tea)
 	db.log = make(map[time.Time]Entry)
 
@@ -106,6 +286,12 @@ func newTeaDb(teas []*Tea, entries []*Entry) (*TeaDb, error) {
 
 	}
 
+	for _, tea := range db.teas {
+		if err := db.validateBlend(tea, make(map[int]struct{})); err != nil {
+			return nil, err
+		}
+	}
+
 	for _, entry := range entries {
 		if entry != nil {
 			db.log[entry.DateTime] = *entry
@@ -3,6 +3,7 @@ package hgtealib
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestNewFilter(t *testing.T) {
@@ -31,6 +32,16 @@ func TestFilterSamplesOnly(t *testing.T) {
 	}
 }
 
+func TestFilterAgingOnly(t *testing.T) {
+	if NewFilter().AgingOnly().agingOnly == false {
+		t.Fatal("AgingOnly is not set to true as expected")
+	}
+
+	if NewFilter().agingOnly != false {
+		t.Fatal("Default value for AgingOnly is not false")
+	}
+}
+
 func TestFilterTypes(t *testing.T) {
 	testTypes := []string{"T1", "T2", "T3"}
 	f := NewFilter().Types(testTypes)
@@ -62,6 +73,46 @@ func TestFilterType(t *testing.T) {
 
 }
 
+func TestFilterGrades(t *testing.T) {
+	f := NewFilter().Grades([]string{"BOP", "", "tippy"})
+
+	if len(f.grades) != 2 {
+		t.Fatalf("Expected 2 grades in the Filter but found: %v", f.grades)
+	}
+
+	if !f.matchesGrade(Tea{LeafGrade: ParseLeafGrade("TGFOP")}) {
+		t.Error("Filter did not match a tea with a tippy leaf grade")
+	}
+
+	if !f.matchesGrade(Tea{LeafGrade: ParseLeafGrade("B.O.P.")}) {
+		t.Error("Filter did not match a tea with a BOP leaf grade")
+	}
+
+	if f.matchesGrade(Tea{LeafGrade: ParseLeafGrade("FOP")}) {
+		t.Error("Filter matched a tea with an unselected leaf grade")
+	}
+}
+
+func TestFilterMinRating(t *testing.T) {
+	f := NewFilter().MinRating("leaf aroma", 3)
+
+	if min, ok := f.ratings["Leaf Aroma"]; !ok || min != 3 {
+		t.Fatalf("Did not find expected product rating in Filter ratings map: %+v", f.ratings)
+	}
+
+	if !f.matchesRatings(Tea{Ratings: map[string]int{"Leaf Aroma": 3}}) {
+		t.Error("Filter did not match a tea with the minimum product rating")
+	}
+
+	if f.matchesRatings(Tea{Ratings: map[string]int{"Leaf Aroma": 2}}) {
+		t.Error("Filter matched a tea below the minimum product rating")
+	}
+
+	if f.matchesRatings(Tea{}) {
+		t.Error("Filter matched a tea without product ratings")
+	}
+}
+
 func TestNewTeaDb(t *testing.T) {
 	if _, err := newTeaDb(testTeas, testEntries); err != nil {
 		t.Fatal(err)
@@ -100,6 +151,39 @@ func TestTeaDbLog(t *testing.T) {
 	}
 }
 
+func TestTeaDbLogFiltered(t *testing.T) {
+	db, err := newTeaDb(testTeas, testEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := db.TeasLog(NewFilter().Type(testTeas[0].Type))
+	if err != nil {
+		t.Error(err)
+	}
+
+	if len(log) != len(testEntries) {
+		t.Fatalf("Found %d log entries but expected %d", len(log), len(testEntries))
+	}
+
+	if log, err = db.TeasLog(NewFilter().Type(createRandomString(1))); err != nil {
+		t.Error(err)
+	}
+
+	if len(log) != 0 {
+		t.Errorf("Unexpectedly found %d log entries of a nonexistent type", len(log))
+	}
+
+	// The log itself is not filtered by the teas
+	if log, err = db.Log(NewFilter().Type(createRandomString(1))); err != nil {
+		t.Error(err)
+	}
+
+	if len(log) != len(testEntries) {
+		t.Errorf("Found %d log entries but expected %d", len(log), len(testEntries))
+	}
+}
+
 func TestTeaDbTeas(t *testing.T) {
 	db, err := newTeaDb(testTeas, testEntries)
 	if err != nil {
@@ -216,3 +300,146 @@ func TestTeaDbTea(t *testing.T) {
 		t.Error("Did not throw error when retrieving unavailable tea id")
 	}
 }
+
+func TestTeaDbTeaByName(t *testing.T) {
+	db, err := newTeaDb(testTeas, testEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]int{
+		"Test Tea #1":        testTeas[0].Id,
+		"test tea #2":        testTeas[1].Id,
+		testTeas[0].String(): testTeas[0].Id,
+	}
+	for name, id := range tests {
+		tea, err := db.TeaByName(name)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if tea.Id != id {
+			t.Errorf("Retrieved tea %d by name '%s' instead of expected: %d", tea.Id, name, id)
+		}
+	}
+
+	if _, err = db.TeaByName("Test Tea"); err == nil {
+		t.Error("Did not throw error when retrieving unavailable tea name")
+	}
+
+	twin := *testTeas[1]
+	twin.Id = 7
+	db, err = newTeaDb(append([]*Tea{&twin}, testTeas...), testEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err = db.TeaByName(twin.Name); err == nil {
+		t.Error("Did not throw error when more than one tea has the name")
+	}
+}
+
+func TestTeaDbFindTea(t *testing.T) {
+	db, err := newTeaDb(testTeas, testEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]int{
+		"42":          testTeas[0].Id,
+		" 101 ":       testTeas[1].Id,
+		"tea #2":      testTeas[1].Id,
+		"2009 Tea #1": testTeas[0].Id,
+	}
+	for idOrName, id := range tests {
+		tea, err := db.FindTea(idOrName)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if tea.Id != id {
+			t.Errorf("Found tea %d by '%s' instead of expected: %d", tea.Id, idOrName, id)
+		}
+	}
+
+	if _, err = db.FindTea("7"); err == nil {
+		t.Error("Did not throw error when finding unavailable tea id")
+	}
+}
+
+func TestTeaDbBlendValidation(t *testing.T) {
+	blend := *testTeas[0]
+	blend.Id = 7
+	blend.Blend = TeaBlend{{Tea: testTeas[0].Id, Ratio: 1}, {Tea: testTeas[1].Id, Ratio: 1}}
+
+	if _, err := newTeaDb(append([]*Tea{&blend}, testTeas...), testEntries); err != nil {
+		t.Fatal(err)
+	}
+
+	unknown := blend
+	unknown.Blend = TeaBlend{{Tea: -1, Ratio: 1}}
+	if _, err := newTeaDb(append([]*Tea{&unknown}, testTeas...), testEntries); err == nil {
+		t.Error("Did not receive expected error when blend references an unknown tea")
+	}
+
+	self := blend
+	self.Blend = TeaBlend{{Tea: self.Id, Ratio: 1}}
+	if _, err := newTeaDb(append([]*Tea{&self}, testTeas...), testEntries); err == nil {
+		t.Error("Did not receive expected error when blend references itself")
+	}
+}
+
+func TestTeaDbConsumption(t *testing.T) {
+	blend := *testTeas[0]
+	blend.Id = 7
+	blend.Blend = TeaBlend{{Tea: testTeas[0].Id, Ratio: 3}, {Tea: testTeas[1].Id, Ratio: 1}}
+
+	entry := *testEntries[0]
+	entry.Tea = blend.Id
+	entry.DateTime = entry.DateTime.Add(-time.Hour)
+
+	db, err := newTeaDb(append([]*Tea{&blend}, testTeas...), append([]*Entry{&entry}, testEntries...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	consumption := db.Consumption()
+
+	if _, ok := consumption[blend.Id]; ok {
+		t.Error("Blend was credited with its own consumption")
+	}
+
+	if consumption[testTeas[0].Id] != 1.75 {
+		t.Errorf("Expected consumption of 1.75 but found %f", consumption[testTeas[0].Id])
+	}
+
+	if consumption[testTeas[1].Id] != 0.25 {
+		t.Errorf("Expected consumption of 0.25 but found %f", consumption[testTeas[1].Id])
+	}
+}
+
+func TestTeaDbLogRatio(t *testing.T) {
+	measured := *testEntries[0]
+	measured.DateTime = measured.DateTime.Add(-time.Hour)
+	measured.LeafGrams = 5
+	measured.WaterMl = 100
+
+	db, err := newTeaDb(testTeas, append([]*Entry{&measured}, testEntries...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log, err := db.Log(NewFilter().Ratio(4, 6))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 1 || !log[0].Equal(&measured) {
+		t.Errorf("Expected only the measured entry but found %d entries", len(log))
+	}
+
+	if log, err = db.Log(NewFilter().Ratio(6, 0)); err != nil {
+		t.Fatal(err)
+	}
+	if len(log) != 0 {
+		t.Errorf("Found %d entries above the ratio", len(log))
+	}
+}
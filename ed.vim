@@ -2,7 +2,7 @@
 
 args *.go teas/*.go Dockerfile README.md
 
-for s:p in [ 'teas/main', 'db', 'types', 'tsv' ]
+for s:p in [ 'teas/main', 'teas/json', 'teas/align', 'teas/columns', 'teas/render', 'teas/template', 'db', 'types', 'tsv', 'stats', 'spend', 'inventory', 'aging', 'grade', 'taxonomy', 'vessels', 'recommend' ]
     execute "tabnew " . s:p . "_test.go"
     topleft vsplit
     execute "edit " . s:p . ".go"
@@ -0,0 +1,271 @@
+package hgtealib
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// FileLockTimeout is how long a change to a file database waits for another process to release its lock
+var FileLockTimeout = 10 * time.Second
+
+// FileLockStale is the age after which the lock of a file database is taken over even if the process that holds it
+// seems to be running, since its id may have been reused
+var FileLockStale = time.Minute
+
+// fileDocument is the layout of a file database
+type fileDocument struct {
+	Teas    []Tea   `json:"teas"`
+	Journal []Entry `json:"journal"`
+}
+
+// fileStore keeps the teas and journal in a local JSON file. Every change locks the file, reads it again so that
+// the changes of other processes are kept, and replaces it by renaming a temporary file over it. The teas and entries
+// that the store has seen are tracked so that one added by another process in the meantime is not overwritten.
+type fileStore struct {
+	path    string
+	teas    map[int]bool
+	entries map[int64]bool
+}
+
+func newFileStore(path string) *fileStore {
+	return &fileStore{path: path, teas: make(map[int]bool), entries: make(map[int64]bool)}
+}
+
+func (s *fileStore) lockPath() string {
+	return s.path + ".lock"
+}
+
+func (s *fileStore) lock() error {
+	deadline := time.Now().Add(FileLockTimeout)
+	for {
+		f, err := os.OpenFile(s.lockPath(), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			return f.Close()
+		}
+		if !os.IsExist(err) {
+			return err
+		}
+		if s.lockStale() {
+			s.takeOver()
+			continue
+		}
+		if time.Now().After(deadline) {
+			return errors.New(fmt.Sprintf("Timed out waiting for the lock on the database, remove %s if no other process holds it", s.lockPath()))
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// lockStale returns true if the process which holds the lock is no longer running or the lock is too old
+func (s *fileStore) lockStale() bool {
+	info, err := os.Stat(s.lockPath())
+	if err != nil {
+		return false
+	}
+	if time.Since(info.ModTime()) > FileLockStale {
+		return true
+	}
+
+	// The process may not have written its id yet
+	data, err := ioutil.ReadFile(s.lockPath())
+	if err != nil {
+		return false
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return false
+	}
+	return !processRunning(pid)
+}
+
+// takeOver removes a stale lock. Only one process at a time takes over a lock, and it checks that the lock is still
+// stale once it does, so that a lock which another process has just taken over and taken again is not removed.
+func (s *fileStore) takeOver() {
+	guard := s.lockPath() + ".takeover"
+	f, err := os.OpenFile(guard, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		// The process taking over the lock is only expected to hold the guard for a moment
+		if info, err := os.Stat(guard); err == nil && time.Since(info.ModTime()) > FileLockStale {
+			os.Remove(guard)
+		}
+		return
+	}
+	f.Close()
+	defer os.Remove(guard)
+
+	if s.lockStale() {
+		os.Remove(s.lockPath())
+	}
+}
+
+func processRunning(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	return p.Signal(syscall.Signal(0)) != os.ErrProcessDone
+}
+
+func (s *fileStore) unlock() {
+	os.Remove(s.lockPath())
+}
+
+func (s *fileStore) read() (fileDocument, error) {
+	var doc fileDocument
+
+	data, err := ioutil.ReadFile(s.path)
+	if os.IsNotExist(err) {
+		return doc, nil
+	}
+	if err != nil {
+		return doc, err
+	}
+
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return doc, errors.New(fmt.Sprintf("Encountered error decoding '%s': %s", s.path, err))
+	}
+	return doc, nil
+}
+
+// write replaces the file with the document, which is first written to a temporary file in the same directory so
+// that the file is never left half written
+func (s *fileStore) write(doc fileDocument) error {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// update applies the change to the current contents of the file while holding its lock
+func (s *fileStore) update(change func(doc *fileDocument) error) error {
+	if err := s.lock(); err != nil {
+		return err
+	}
+	defer s.unlock()
+
+	doc, err := s.read()
+	if err != nil {
+		return err
+	}
+	if err := change(&doc); err != nil {
+		return err
+	}
+	return s.write(doc)
+}
+
+func (s *fileStore) PutTea(tea Tea) error {
+	return s.update(func(doc *fileDocument) error {
+		for i, t := range doc.Teas {
+			if t.Id == tea.Id {
+				if !s.teas[tea.Id] {
+					return errors.New(fmt.Sprintf("Tea %d was added by another process", tea.Id))
+				}
+				doc.Teas[i] = tea
+				return nil
+			}
+		}
+		doc.Teas = append(doc.Teas, tea)
+		s.teas[tea.Id] = true
+		return nil
+	})
+}
+
+func (s *fileStore) DeleteTea(id int) error {
+	return s.update(func(doc *fileDocument) error {
+		teas := make([]Tea, 0, len(doc.Teas))
+		for _, t := range doc.Teas {
+			if t.Id != id {
+				teas = append(teas, t)
+			}
+		}
+		doc.Teas = teas
+		delete(s.teas, id)
+		return nil
+	})
+}
+
+func (s *fileStore) PutEntry(entry Entry) error {
+	key := entry.DateTime.UnixNano()
+	return s.update(func(doc *fileDocument) error {
+		for i, e := range doc.Journal {
+			if e.DateTime.Equal(entry.DateTime) {
+				if !s.entries[key] {
+					return errors.New(fmt.Sprintf("An entry at %s was added by another process", entry.DateTime))
+				}
+				doc.Journal[i] = entry
+				return nil
+			}
+		}
+		doc.Journal = append(doc.Journal, entry)
+		s.entries[key] = true
+		return nil
+	})
+}
+
+func (s *fileStore) DeleteEntry(at time.Time) error {
+	return s.update(func(doc *fileDocument) error {
+		journal := make([]Entry, 0, len(doc.Journal))
+		for _, e := range doc.Journal {
+			if !e.DateTime.Equal(at) {
+				journal = append(journal, e)
+			}
+		}
+		doc.Journal = journal
+		delete(s.entries, at.UnixNano())
+		return nil
+	})
+}
+
+// NewFromFile loads the teas and journal from a local JSON file, which all changes to the database are written to.
+// The file is created with the first change if it does not exist.
+func NewFromFile(path string) (*TeaDb, error) {
+	store := newFileStore(path)
+	doc, err := store.read()
+	if err != nil {
+		return nil, err
+	}
+
+	teas := make([]*Tea, len(doc.Teas))
+	for i := range doc.Teas {
+		teas[i] = &doc.Teas[i]
+		store.teas[doc.Teas[i].Id] = true
+	}
+
+	entries := make([]*Entry, len(doc.Journal))
+	for i := range doc.Journal {
+		doc.Journal[i].ParseTags()
+		entries[i] = &doc.Journal[i]
+		store.entries[doc.Journal[i].DateTime.UnixNano()] = true
+	}
+
+	return NewTeaDb(store, teas, entries)
+}
@@ -0,0 +1,332 @@
+package hgtealib
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestFileDb(t *testing.T) (*TeaDb, string) {
+	dir, err := ioutil.TempDir("", "hgtealib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "teas.json")
+
+	db, err := NewFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, path
+}
+
+func TestNewFromFile(t *testing.T) {
+	db, path := newTestFileDb(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	if teas, _ := db.Teas(NewFilter()); len(teas) != 0 {
+		t.Fatalf("Expected an empty database but found %d teas", len(teas))
+	}
+	if db.ReadOnly() {
+		t.Error("File database is read-only")
+	}
+
+	tea, err := db.AddTea(Tea{Name: "Dong Ding", Type: "Oolong", Ratings: map[string]int{"Value": 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	at := time.Date(2018, 3, 1, 8, 30, 0, 0, time.UTC)
+	entry := Entry{Tea: tea.Id, DateTime: at, Rating: 3, Comments: "roasted #evening", SteepTime: 45 * time.Second, SteepingVessel: Gaiwan, Fixins: []TeaFixin{Honey}}
+	if err := db.AddEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := reopened.Tea(tea.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.Name != "Dong Ding" || loaded.Type != "Oolong" || loaded.Ratings["Value"] != 3 || loaded.LogLen() != 1 {
+		t.Errorf("Reloaded tea does not match: %+v", loaded)
+	}
+
+	e, err := reopened.Entry(at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Rating != 3 || e.SteepTime != 45*time.Second || e.SteepingVessel != Gaiwan || len(e.Fixins) != 1 || len(e.Tags) != 2 {
+		t.Errorf("Reloaded entry does not match: %+v", e)
+	}
+
+	if err := reopened.DeleteEntry(at); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.DeleteTea(tea.Id); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err = NewFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if teas, _ := reopened.Teas(NewFilter()); len(teas) != 0 {
+		t.Errorf("Expected the tea to be deleted but found %d teas", len(teas))
+	}
+
+	files, _ := ioutil.ReadDir(filepath.Dir(path))
+	if len(files) != 1 {
+		t.Errorf("Expected only the database file but found %d files", len(files))
+	}
+}
+
+func TestFileStoreConcurrentChanges(t *testing.T) {
+	db, path := newTestFileDb(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	tea, err := db.AddTea(Tea{Name: "Bai Mu Dan"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each process has its own view of the database, which does not include the entries of the others
+	const processes = 8
+	dbs := make([]*TeaDb, processes)
+	for i := range dbs {
+		if dbs[i], err = NewFromFile(path); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	errs := make(chan error, processes)
+	start := time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, d := range dbs {
+		wg.Add(1)
+		go func(i int, d *TeaDb) {
+			defer wg.Done()
+			errs <- d.AddEntry(Entry{Tea: tea.Id, DateTime: start.Add(time.Duration(i) * time.Hour), Rating: 2})
+		}(i, d)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reopened, err := NewFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log, _ := reopened.Log(NewFilter()); len(log) != processes {
+		t.Errorf("Expected %d entries but found %d", processes, len(log))
+	}
+}
+
+func TestFileStoreLocked(t *testing.T) {
+	db, path := newTestFileDb(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	if err := ioutil.WriteFile(path+".lock", []byte("1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := FileLockTimeout
+	FileLockTimeout = 100 * time.Millisecond
+	defer func() { FileLockTimeout = timeout }()
+
+	if _, err := db.AddTea(Tea{Name: "Sencha"}); err == nil {
+		t.Fatal("Did not receive expected error while the database is locked")
+	}
+	if teas, _ := db.Teas(NewFilter()); len(teas) != 0 {
+		t.Error("Tea was added even though it could not be written")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("Database file was written while it was locked")
+	}
+}
+
+func TestFileStoreStaleLock(t *testing.T) {
+	db, path := newTestFileDb(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	timeout := FileLockTimeout
+	FileLockTimeout = 100 * time.Millisecond
+	defer func() { FileLockTimeout = timeout }()
+
+	// The lock of a process which is no longer running is taken over
+	if err := ioutil.WriteFile(path+".lock", []byte("1073741824\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AddTea(Tea{Name: "Sencha"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// An old lock is taken over even if its process is running
+	if err := ioutil.WriteFile(path+".lock", []byte("1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-2 * FileLockStale)
+	if err := os.Chtimes(path+".lock", old, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AddTea(Tea{Name: "Gyokuro"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Error("Lock was not released")
+	}
+	if teas, _ := db.Teas(NewFilter()); len(teas) != 2 {
+		t.Errorf("Expected 2 teas but found %d", len(teas))
+	}
+}
+
+func TestFileStoreStaleLockConcurrent(t *testing.T) {
+	_, path := newTestFileDb(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	// Every waiter finds the same stale lock, but only one at a time may hold the lock once it is taken over
+	const waiters = 8
+	for i := 0; i < 5; i++ {
+		if err := ioutil.WriteFile(path+".lock", []byte("1073741824\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		var wg sync.WaitGroup
+		var mu sync.Mutex
+		holders, most := 0, 0
+		errs := make(chan error, waiters)
+		for w := 0; w < waiters; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				errs <- newFileStore(path).update(func(doc *fileDocument) error {
+					mu.Lock()
+					holders++
+					if holders > most {
+						most = holders
+					}
+					mu.Unlock()
+
+					time.Sleep(time.Millisecond)
+
+					mu.Lock()
+					holders--
+					mu.Unlock()
+					return nil
+				})
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+		if most != 1 {
+			t.Fatalf("%d processes held the lock at once", most)
+		}
+	}
+}
+
+func TestFileStoreTakeOver(t *testing.T) {
+	_, path := newTestFileDb(t)
+	defer os.RemoveAll(filepath.Dir(path))
+	store := newFileStore(path)
+
+	// A waiter which found the lock stale does not remove it once another process has taken it again
+	if err := ioutil.WriteFile(path+".lock", []byte(fmt.Sprintf("%d\n", os.Getpid())), 0644); err != nil {
+		t.Fatal(err)
+	}
+	store.takeOver()
+	if _, err := os.Stat(path + ".lock"); err != nil {
+		t.Error("Lock which is no longer stale was removed")
+	}
+
+	// Nor while another process is taking it over
+	if err := ioutil.WriteFile(path+".lock", []byte("1073741824\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path+".lock.takeover", nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	store.takeOver()
+	if _, err := os.Stat(path + ".lock"); err != nil {
+		t.Error("Lock was removed while another process was taking it over")
+	}
+
+	os.Remove(path + ".lock.takeover")
+	store.takeOver()
+	if _, err := os.Stat(path + ".lock"); !os.IsNotExist(err) {
+		t.Error("Stale lock was not removed")
+	}
+	if _, err := os.Stat(path + ".lock.takeover"); !os.IsNotExist(err) {
+		t.Error("Guard of the lock was not removed")
+	}
+}
+
+func TestNewFromFileInvalid(t *testing.T) {
+	_, path := newTestFileDb(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	if err := ioutil.WriteFile(path, []byte("{\"teas\": ["), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewFromFile(path); err == nil {
+		t.Error("Did not receive expected error from a badly formatted file")
+	}
+}
+
+func TestFileStoreConflict(t *testing.T) {
+	db, path := newTestFileDb(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	tea, err := db.AddTea(Tea{Name: "Bai Mu Dan"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	other, err := NewFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2018, 1, 1, 8, 0, 0, 0, time.UTC)
+	if err := db.AddEntry(Entry{Tea: tea.Id, DateTime: at, Rating: 2}); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.AddEntry(Entry{Tea: tea.Id, DateTime: at, Rating: 4}); err == nil {
+		t.Error("Did not receive expected error when adding an entry at the same time as another process")
+	}
+	if _, err := other.AddTea(Tea{Name: "Sencha"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AddTea(Tea{Name: "Gyokuro"}); err == nil {
+		t.Error("Did not receive expected error when adding a tea with the same id as another process")
+	}
+
+	// Changing an entry this process knows of is not a conflict
+	if err := db.UpdateEntry(at, Entry{Tea: tea.Id, DateTime: at, Rating: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFromFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e, _ := reopened.Entry(at); e.Rating != 3 {
+		t.Errorf("Entry has rating %d instead of expected: 3", e.Rating)
+	}
+	if teas, _ := reopened.Teas(NewFilter()); len(teas) != 2 {
+		t.Errorf("Expected 2 teas but found %d", len(teas))
+	}
+}
@@ -0,0 +1,218 @@
+package hgtealib
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type LeafStyle int
+
+const (
+	UnknownLeafStyle LeafStyle = 0 + iota
+	WholeLeaf
+	BrokenLeaf
+	Fannings
+	Dust
+	CTC
+)
+
+func (s LeafStyle) String() string {
+	switch s {
+	case WholeLeaf:
+		return "Whole"
+	case BrokenLeaf:
+		return "Broken"
+	case Fannings:
+		return "Fannings"
+	case Dust:
+		return "Dust"
+	case CTC:
+		return "CTC"
+	default:
+		return ""
+	}
+}
+
+// TeaLeafGrade is an orthodox leaf grade, such as FTGFOP1, broken down into its parts
+type TeaLeafGrade struct {
+	Raw     string
+	Style   LeafStyle
+	Base    string
+	Special bool
+	Finest  bool
+	Tippy   bool
+	Golden  bool
+	Flowery bool
+	Broken  bool
+	Quality int
+}
+
+var leafGradeBases = map[string]string{
+	"OP": "Orange Pekoe",
+	"P":  "Pekoe",
+	"S":  "Souchong",
+}
+
+var reLeafGrade = regexp.MustCompile("^(S)?(FT)?(T)?(G)?(F)?(B)?(OP|P|S)?(F|D)?([0-9]*)$")
+
+// ParseLeafGrade breaks down an orthodox leaf grade code. Grades which are not recognized are kept verbatim.
+func ParseLeafGrade(s string) TeaLeafGrade {
+	g := TeaLeafGrade{Raw: s}
+
+	code := strings.ToUpper(strings.Replace(strings.Join(strings.Fields(s), ""), ".", "", -1))
+	switch code {
+	case "":
+		return g
+	case "CTC":
+		g.Style = CTC
+		return g
+	case "F", "OF":
+		g.Style = Fannings
+		return g
+	case "D":
+		g.Style = Dust
+		return g
+	case "S":
+		// The regex would take a bare S as the Special prefix
+		g.Base = "S"
+		g.Style = WholeLeaf
+		return g
+	}
+
+	m := reLeafGrade.FindStringSubmatch(code)
+	if m == nil || (m[7] == "" && m[8] == "") {
+		return g
+	}
+
+	g.Special = m[1] != ""
+	g.Finest = m[2] != ""
+	g.Tippy = m[2] != "" || m[3] != ""
+	g.Golden = m[4] != ""
+	g.Flowery = m[5] != ""
+	g.Broken = m[6] != ""
+	g.Base = m[7]
+	g.Quality, _ = strconv.Atoi(m[9])
+
+	switch {
+	case m[8] == "F":
+		g.Style = Fannings
+	case m[8] == "D":
+		g.Style = Dust
+	case g.Broken:
+		g.Style = BrokenLeaf
+	default:
+		g.Style = WholeLeaf
+	}
+
+	return g
+}
+
+func (g TeaLeafGrade) Known() bool {
+	return g.Style != UnknownLeafStyle
+}
+
+// Code returns the canonical code of the grade, or the grade verbatim if it was not recognized
+func (g TeaLeafGrade) Code() string {
+	if !g.Known() || g.Style == CTC || g.Base == "" {
+		return strings.TrimSpace(g.Raw)
+	}
+
+	var buf bytes.Buffer
+	for _, p := range []struct {
+		set  bool
+		code string
+	}{
+		{g.Special, "S"},
+		{g.Finest, "F"},
+		{g.Tippy, "T"},
+		{g.Golden, "G"},
+		{g.Flowery, "F"},
+		{g.Broken, "B"},
+	} {
+		if p.set {
+			buf.WriteString(p.code)
+		}
+	}
+	buf.WriteString(g.Base)
+
+	switch g.Style {
+	case Fannings:
+		buf.WriteString("F")
+	case Dust:
+		buf.WriteString("D")
+	}
+
+	if g.Quality > 0 {
+		buf.WriteString(strconv.Itoa(g.Quality))
+	}
+
+	return buf.String()
+}
+
+// Description spells out the grade, such as "Finest Tippy Golden Flowery Orange Pekoe 1"
+func (g TeaLeafGrade) Description() string {
+	if !g.Known() {
+		return strings.TrimSpace(g.Raw)
+	}
+	if g.Base == "" {
+		return g.Style.String()
+	}
+
+	parts := make([]string, 0)
+	for _, p := range []struct {
+		set  bool
+		name string
+	}{
+		{g.Special, "Special"},
+		{g.Finest, "Finest"},
+		{g.Tippy, "Tippy"},
+		{g.Golden, "Golden"},
+		{g.Flowery, "Flowery"},
+		{g.Broken, "Broken"},
+	} {
+		if p.set {
+			parts = append(parts, p.name)
+		}
+	}
+	parts = append(parts, leafGradeBases[g.Base])
+
+	switch g.Style {
+	case Fannings, Dust:
+		parts = append(parts, g.Style.String())
+	}
+
+	if g.Quality > 0 {
+		parts = append(parts, strconv.Itoa(g.Quality))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// Is returns true if the grade has the given code, style or part, such as "FTGFOP1", "broken" or "tippy"
+func (g TeaLeafGrade) Is(v string) bool {
+	v = strings.TrimSpace(v)
+	switch strings.ToLower(v) {
+	case "":
+		return false
+	case "special":
+		return g.Special
+	case "finest":
+		return g.Finest
+	case "tippy":
+		return g.Tippy
+	case "golden":
+		return g.Golden
+	case "flowery":
+		return g.Flowery
+	case "broken":
+		return g.Broken
+	}
+
+	return strings.EqualFold(v, g.Code()) || strings.EqualFold(v, g.Style.String()) || strings.EqualFold(v, g.Raw)
+}
+
+func (g TeaLeafGrade) String() string {
+	return g.Raw
+}
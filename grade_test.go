@@ -0,0 +1,74 @@
+package hgtealib
+
+import "testing"
+
+func TestParseLeafGrade(t *testing.T) {
+	tests := []struct {
+		raw   string
+		code  string
+		style LeafStyle
+		desc  string
+	}{
+		{"OP", "OP", WholeLeaf, "Orange Pekoe"},
+		{"FOP", "FOP", WholeLeaf, "Flowery Orange Pekoe"},
+		{"GFOP", "GFOP", WholeLeaf, "Golden Flowery Orange Pekoe"},
+		{"TGFOP", "TGFOP", WholeLeaf, "Tippy Golden Flowery Orange Pekoe"},
+		{"ftgfop1", "FTGFOP1", WholeLeaf, "Finest Tippy Golden Flowery Orange Pekoe 1"},
+		{"S.F.T.G.F.O.P. 1", "SFTGFOP1", WholeLeaf, "Special Finest Tippy Golden Flowery Orange Pekoe 1"},
+		{"BOP", "BOP", BrokenLeaf, "Broken Orange Pekoe"},
+		{"S", "S", WholeLeaf, "Souchong"},
+		{"BS", "BS", BrokenLeaf, "Broken Souchong"},
+		{"FBOPF", "FBOPF", Fannings, "Flowery Broken Orange Pekoe Fannings"},
+		{"PF", "PF", Fannings, "Pekoe Fannings"},
+		{"PD", "PD", Dust, "Pekoe Dust"},
+		{"F", "F", Fannings, "Fannings"},
+		{"D", "D", Dust, "Dust"},
+		{"ctc", "ctc", CTC, "CTC"},
+		{"STFTGFOPOMG!", "STFTGFOPOMG!", UnknownLeafStyle, "STFTGFOPOMG!"},
+		{"Imperial", "Imperial", UnknownLeafStyle, "Imperial"},
+	}
+
+	for _, test := range tests {
+		g := ParseLeafGrade(test.raw)
+
+		if g.String() != test.raw {
+			t.Errorf("Leaf grade '%s' was not kept verbatim: '%s'", test.raw, g.String())
+		}
+
+		if g.Code() != test.code {
+			t.Errorf("Expected leaf grade '%s' to have code '%s' but found '%s'", test.raw, test.code, g.Code())
+		}
+
+		if g.Style != test.style {
+			t.Errorf("Expected leaf grade '%s' to have style '%s' but found '%s'", test.raw, test.style, g.Style)
+		}
+
+		if g.Description() != test.desc {
+			t.Errorf("Expected leaf grade '%s' to be described as '%s' but found '%s'", test.raw, test.desc, g.Description())
+		}
+	}
+
+	if g := ParseLeafGrade(""); g.Known() || g.Code() != "" {
+		t.Errorf("Unexpected leaf grade from an empty value: %+v", g)
+	}
+}
+
+func TestLeafGradeIs(t *testing.T) {
+	g := ParseLeafGrade("FTGFOP1")
+
+	for _, v := range []string{"FTGFOP1", "ftgfop1", "whole", "tippy", "Golden", "flowery", "finest"} {
+		if !g.Is(v) {
+			t.Errorf("Expected leaf grade %s to be '%s'", g, v)
+		}
+	}
+
+	for _, v := range []string{"", "broken", "special", "FTGFOP", "fannings"} {
+		if g.Is(v) {
+			t.Errorf("Did not expect leaf grade %s to be '%s'", g, v)
+		}
+	}
+
+	if !ParseLeafGrade("Imperial").Is("imperial") {
+		t.Error("Unknown leaf grade did not match itself verbatim")
+	}
+}
@@ -0,0 +1,120 @@
+package hgtealib
+
+import (
+	"strings"
+	"time"
+)
+
+// DefaultLeafPerSession is the amount of leaf, in grams, assumed to be used in a session of a given type of tea
+var DefaultLeafPerSession = map[string]float64{
+	"Black":  3,
+	"Green":  3,
+	"White":  4,
+	"Oolong": 6,
+	"Pu-erh": 7,
+}
+
+// FallbackLeafPerSession is used for teas whose type has no default leaf amount
+var FallbackLeafPerSession = 3.0
+
+// InventoryRateWindow is how far back the journal is looked at to determine the current rate of drinking
+var InventoryRateWindow = 90 * 24 * time.Hour
+
+// SessionLeaf returns the grams of leaf used in a session of the tea, either as measured or the default for its type
+// or the closest of its parent types
+func (t *Tea) SessionLeaf() float64 {
+	if t.LeafPerSession > 0 {
+		return t.LeafPerSession
+	}
+
+	var total float64
+	var count int
+	for _, entry := range t.log {
+		if entry.LeafGrams > 0 {
+			total += entry.LeafGrams
+			count++
+		}
+	}
+	if count > 0 {
+		return total / float64(count)
+	}
+
+	for _, ancestor := range TeaTypes.Ancestors(t.Type) {
+		for teaType, grams := range DefaultLeafPerSession {
+			if strings.EqualFold(TeaTypes.Canonical(teaType), ancestor) {
+				return grams
+			}
+		}
+	}
+
+	return FallbackLeafPerSession
+}
+
+type TeaInventory struct {
+	Quantity  float64
+	Used      float64
+	Remaining float64
+	DailyRate float64
+	Finished  bool
+}
+
+// DaysLeft returns the estimated number of days until the tea runs out, or -1 when it is not being drunk
+func (i TeaInventory) DaysLeft() float64 {
+	if i.DailyRate <= 0 {
+		return -1
+	}
+	return i.Remaining / i.DailyRate
+}
+
+func (d *TeaDb) sessionUse(tea Tea, since time.Time, use map[int]float64) {
+	sessions := make(map[string]struct{})
+	for _, entry := range tea.log {
+		if entry.DateTime.Before(since) {
+			continue
+		}
+		if entry.SessionInstance != "" {
+			if _, ok := sessions[entry.SessionInstance]; ok {
+				continue
+			}
+			sessions[entry.SessionInstance] = struct{}{}
+		}
+		d.credit(use, tea.Id, tea.SessionLeaf())
+	}
+}
+
+// Inventory estimates how much of each of the filtered teas is left, based on the journal
+func (d *TeaDb) Inventory(filter *Filter, now time.Time) (map[int]TeaInventory, error) {
+	teas, err := d.Teas(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	used := make(map[int]float64)
+	recent := make(map[int]float64)
+	for _, tea := range d.teas {
+		d.sessionUse(tea, time.Time{}, used)
+		d.sessionUse(tea, now.Add(-InventoryRateWindow), recent)
+	}
+
+	inventory := make(map[int]TeaInventory)
+	for id, tea := range teas {
+		if tea.IsBlend() {
+			continue
+		}
+
+		i := TeaInventory{
+			Quantity:  tea.Quantity,
+			Used:      used[id],
+			DailyRate: recent[id] / InventoryRateWindow.Hours() * 24,
+		}
+
+		if i.Remaining = i.Quantity - i.Used; i.Remaining < 0 {
+			i.Remaining = 0
+		}
+		i.Finished = i.Quantity > 0 && i.Remaining == 0 && tea.Storage.Stocked
+
+		inventory[id] = i
+	}
+
+	return inventory, nil
+}
@@ -0,0 +1,91 @@
+package hgtealib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTeaSessionLeaf(t *testing.T) {
+	tea := Tea{Type: "oolong"}
+	if tea.SessionLeaf() != DefaultLeafPerSession["Oolong"] {
+		t.Errorf("Expected the default oolong leaf amount but found %f", tea.SessionLeaf())
+	}
+
+	tea.LeafPerSession = 5.5
+	if tea.SessionLeaf() != 5.5 {
+		t.Errorf("Expected the measured leaf amount but found %f", tea.SessionLeaf())
+	}
+
+	tea = Tea{Type: createRandomString(1)}
+	if tea.SessionLeaf() != FallbackLeafPerSession {
+		t.Errorf("Expected the fallback leaf amount but found %f", tea.SessionLeaf())
+	}
+}
+
+func TestTeaInventoryDaysLeft(t *testing.T) {
+	if days := (TeaInventory{Remaining: 30, DailyRate: 3}).DaysLeft(); days != 10 {
+		t.Errorf("Expected 10 days left but found %f", days)
+	}
+
+	if days := (TeaInventory{Remaining: 30}).DaysLeft(); days != -1 {
+		t.Errorf("Expected no estimate without a daily rate but found %f", days)
+	}
+}
+
+func TestTeaDbInventory(t *testing.T) {
+	now := time.Now()
+
+	teas := []*Tea{
+		{Id: 1, Type: "Black", Quantity: 100, LeafPerSession: 5, Storage: TeaStorageState{Stocked: true}},
+		{Id: 2, Type: "Black", Quantity: 10, LeafPerSession: 5, Storage: TeaStorageState{Stocked: true}},
+		{Id: 3, Type: "Black", LeafPerSession: 4, Blend: TeaBlend{{Tea: 1, Ratio: 1}, {Tea: 2, Ratio: 1}}},
+	}
+
+	entries := []*Entry{
+		// Two steeps of the same session only use the leaf once
+		{Tea: 1, DateTime: now.Add(-time.Hour), SessionInstance: "A"},
+		{Tea: 1, DateTime: now.Add(-2 * time.Hour), SessionInstance: "A"},
+		{Tea: 1, DateTime: now.Add(-InventoryRateWindow * 2), SessionInstance: "B"},
+		{Tea: 2, DateTime: now.Add(-3 * time.Hour)},
+		{Tea: 3, DateTime: now.Add(-4 * time.Hour)},
+	}
+
+	db, err := newTeaDb(teas, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inventory, err := db.Inventory(NewFilter(), now)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := inventory[3]; ok {
+		t.Error("Found inventory for a blend")
+	}
+
+	if i := inventory[1]; i.Used != 12 || i.Remaining != 88 || i.Finished {
+		t.Errorf("Unexpected inventory for tea 1: %+v", i)
+	}
+
+	if expected := 7 / InventoryRateWindow.Hours() * 24; inventory[1].DailyRate != expected {
+		t.Errorf("Expected daily rate of %f but found %f", expected, inventory[1].DailyRate)
+	}
+
+	if i := inventory[2]; i.Used != 7 || i.Remaining != 3 || i.Finished {
+		t.Errorf("Unexpected inventory for tea 2: %+v", i)
+	}
+
+	entries = append(entries, &Entry{Tea: 2, DateTime: now.Add(-5 * time.Hour)})
+	if db, err = newTeaDb(teas, entries); err != nil {
+		t.Fatal(err)
+	}
+
+	if inventory, err = db.Inventory(NewFilter(), now); err != nil {
+		t.Fatal(err)
+	}
+
+	if i := inventory[2]; i.Remaining != 0 || !i.Finished {
+		t.Errorf("Expected tea 2 to be finished: %+v", i)
+	}
+}
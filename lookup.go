@@ -0,0 +1,199 @@
+package hgtealib
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// AmbiguousTeaError is returned when a name matches more than one tea equally well
+type AmbiguousTeaError struct {
+	Name       string
+	Candidates []Tea
+}
+
+func (e AmbiguousTeaError) Error() string {
+	var buf bytes.Buffer
+	buf.WriteString(fmt.Sprintf("More than one tea matches '%s':", e.Name))
+	for _, tea := range e.Candidates {
+		buf.WriteString(fmt.Sprintf("\n  %d: %s", tea.Id, tea.String()))
+	}
+	return buf.String()
+}
+
+func tokenize(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// editDistance returns the number of single character insertions, deletions, substitutions or transpositions of
+// adjacent characters needed to turn one string into the other
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+
+	min := func(values ...int) int {
+		m := values[0]
+		for _, v := range values[1:] {
+			if v < m {
+				m = v
+			}
+		}
+		return m
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+
+	return d[len(ra)][len(rb)]
+}
+
+// similar returns true if the token is close enough to the word to be a typo of it, allowing one edit per four
+// characters
+func similar(token, word string) bool {
+	allowed := len([]rune(token)) / 4
+	if allowed < 1 {
+		allowed = 1
+	}
+	return editDistance(token, word) <= allowed
+}
+
+// splitYear separates a leading or trailing year, i.e.: "2016 Bai Mu Dan", from the rest of the name. Only numbers
+// which could be the year a tea was picked are years, so that the recipe of a pu-erh, i.e.: 7542, is kept in the name.
+func splitYear(name string) (int, string) {
+	fields := strings.Fields(name)
+	if len(fields) < 2 {
+		return 0, name
+	}
+
+	for _, i := range []int{0, len(fields) - 1} {
+		if year, err := strconv.Atoi(fields[i]); err == nil && len(fields[i]) == 4 && year >= 1900 && year <= time.Now().Year()+1 {
+			rest := append(append([]string{}, fields[:i]...), fields[i+1:]...)
+			return year, strings.Join(rest, " ")
+		}
+	}
+	return 0, name
+}
+
+// teaMatchers are tried in order until one matches any teas, from the exact name to a name with typos
+var teaMatchers = []func(name string, tea Tea) bool{
+	func(name string, tea Tea) bool {
+		return strings.EqualFold(tea.Name, name)
+	},
+	func(name string, tea Tea) bool {
+		return strings.Contains(strings.ToLower(tea.Name), strings.ToLower(name))
+	},
+	func(name string, tea Tea) bool {
+		words := tokenize(tea.Name)
+		for _, token := range tokenize(name) {
+			var found bool
+			for _, word := range words {
+				if strings.Contains(word, token) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return len(words) > 0
+	},
+	func(name string, tea Tea) bool {
+		words := tokenize(tea.Name)
+		for _, token := range tokenize(name) {
+			var found bool
+			for _, word := range words {
+				if similar(token, word) {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		}
+		return len(words) > 0
+	},
+}
+
+// exactTeas returns the teas whose name, with or without the year, is the given name regardless of case
+func exactTeas(teas map[int]Tea, name string) []Tea {
+	candidates := make([]Tea, 0)
+	for _, tea := range teas {
+		if strings.EqualFold(tea.Name, name) || strings.EqualFold(tea.String(), name) {
+			candidates = append(candidates, tea)
+		}
+	}
+	return candidates
+}
+
+// matchTeas returns the teas found by the first of the matchers to find any, which were picked in the year unless it
+// is zero
+func matchTeas(teas map[int]Tea, year int, name string) []Tea {
+	candidates := make([]Tea, 0)
+	for _, match := range teaMatchers {
+		for _, tea := range teas {
+			if (year == 0 || tea.Picked.Year == year) && match(name, tea) {
+				candidates = append(candidates, tea)
+			}
+		}
+		if len(candidates) > 0 {
+			break
+		}
+	}
+	return candidates
+}
+
+// resolveTea finds the tea with the given name. The name is matched regardless of case, and can be part of the name
+// of the tea, its words in any order or misspelled. A year before or after the name only matches teas picked that
+// year.
+func resolveTea(teas map[int]Tea, name string) (Tea, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return *new(Tea), errors.New("Expected the name of a tea")
+	}
+
+	candidates := exactTeas(teas, name)
+
+	year, rest := splitYear(name)
+	if len(candidates) == 0 {
+		candidates = matchTeas(teas, year, rest)
+	}
+	// The number may have been part of the name after all
+	if len(candidates) == 0 && year != 0 {
+		candidates = matchTeas(teas, 0, name)
+	}
+
+	switch {
+	case len(candidates) == 0:
+		return *new(Tea), errors.New(fmt.Sprintf("Could not retrieve Tea by name: %s", name))
+	case len(candidates) > 1:
+		sort.Slice(candidates, func(i, j int) bool { return candidates[i].Id < candidates[j].Id })
+		return *new(Tea), AmbiguousTeaError{Name: name, Candidates: candidates}
+	}
+	return candidates[0], nil
+}
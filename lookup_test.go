@@ -0,0 +1,108 @@
+package hgtealib
+
+import (
+	"testing"
+)
+
+var testLookupTeas = map[int]Tea{
+	1: {Id: 1, Name: "Bai Mu Dan", Picked: TeaPickPeriod{Year: 2016}},
+	2: {Id: 2, Name: "Bai Mu Dan", Picked: TeaPickPeriod{Year: 2018}},
+	3: {Id: 3, Name: "Margaret's Hope", Picked: TeaPickPeriod{Year: 2017}},
+	4: {Id: 4, Name: "Dong Ding"},
+	5: {Id: 5, Name: "1001 Nights"},
+	6: {Id: 6, Name: "Menghai Dayi 7542", Picked: TeaPickPeriod{Year: 2011}},
+	7: {Id: 7, Name: "Pu-erh 2010 Cake"},
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		distance int
+	}{
+		{"", "", 0},
+		{"dan", "dan", 0},
+		{"dan", "", 3},
+		{"dan", "din", 1},
+		{"bia", "bai", 1},
+		{"margret", "margaret", 1},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, test := range tests {
+		if d := editDistance(test.a, test.b); d != test.distance {
+			t.Errorf("Edit distance of '%s' and '%s' is %d instead of expected: %d", test.a, test.b, d, test.distance)
+		}
+	}
+}
+
+func TestSplitYear(t *testing.T) {
+	tests := map[string]struct {
+		year int
+		rest string
+	}{
+		"2016 Bai Mu Dan": {2016, "Bai Mu Dan"},
+		"Bai Mu Dan 2016": {2016, "Bai Mu Dan"},
+		"Bai Mu Dan":      {0, "Bai Mu Dan"},
+		"2016":            {0, "2016"},
+		"Menghai 7542":    {0, "Menghai 7542"},
+		"1899 Bai Mu Dan": {0, "1899 Bai Mu Dan"},
+	}
+
+	for name, expected := range tests {
+		year, rest := splitYear(name)
+		if year != expected.year || rest != expected.rest {
+			t.Errorf("Split '%s' into %d and '%s' instead of expected: %d and '%s'", name, year, rest, expected.year, expected.rest)
+		}
+	}
+}
+
+func TestResolveTea(t *testing.T) {
+	tests := map[string]int{
+		"2016 Bai Mu Dan": 1,
+		"bai mu dan 2018": 2,
+		"margaret's hope": 3,
+		"hope":            3,
+		"ding dong":       4,
+		"Dong Dign":       4,
+		"margret":         3,
+		"1001 Nights":     5,
+		"2016 mu":         1,
+		"dayi 7542":       6,
+		"2011 dayi 7542":  6,
+		"2010 cake":       7,
+	}
+
+	for name, id := range tests {
+		tea, err := resolveTea(testLookupTeas, name)
+		if err != nil {
+			t.Errorf("Unable to resolve '%s': %s", name, err)
+			continue
+		}
+		if tea.Id != id {
+			t.Errorf("Resolved '%s' to tea %d instead of expected: %d", name, tea.Id, id)
+		}
+	}
+
+	for _, name := range []string{"", "Sencha", "2017 Bai Mu Dan"} {
+		if _, err := resolveTea(testLookupTeas, name); err == nil {
+			t.Errorf("Did not throw error when resolving unavailable tea name '%s'", name)
+		}
+	}
+}
+
+func TestResolveTeaAmbiguous(t *testing.T) {
+	_, err := resolveTea(testLookupTeas, "bai mu dan")
+	ambiguous, ok := err.(AmbiguousTeaError)
+	if !ok {
+		t.Fatalf("Expected an AmbiguousTeaError but received: %v", err)
+	}
+
+	if len(ambiguous.Candidates) != 2 || ambiguous.Candidates[0].Id != 1 || ambiguous.Candidates[1].Id != 2 {
+		t.Errorf("Unexpected candidates: %v", ambiguous.Candidates)
+	}
+
+	expected := "More than one tea matches 'bai mu dan':\n  1: 2016 Bai Mu Dan\n  2: 2018 Bai Mu Dan"
+	if ambiguous.Error() != expected {
+		t.Errorf("Error message '%s' does not match expected: %s", ambiguous.Error(), expected)
+	}
+}
@@ -0,0 +1,74 @@
+package hgtealib
+
+import (
+	"sort"
+	"time"
+)
+
+type SteepingRecommendation struct {
+	Entries     int
+	Rating      int
+	SteepTime   time.Duration
+	Temperature int
+	Ratio       float64
+	Vessel      string
+}
+
+// Recommendation suggests how to steep the tea, based on the median steeping parameters of its best rated entries
+func (t *Tea) Recommendation() (SteepingRecommendation, bool) {
+	var r SteepingRecommendation
+
+	best := make([]Entry, 0)
+	for _, entry := range t.log {
+		switch {
+		case len(best) == 0 || entry.Rating > r.Rating:
+			r.Rating = entry.Rating
+			best = []Entry{entry}
+		case entry.Rating == r.Rating:
+			best = append(best, entry)
+		}
+	}
+
+	if len(best) == 0 {
+		return r, false
+	}
+	r.Entries = len(best)
+
+	times := make([]float64, 0)
+	temps := make([]float64, 0)
+	ratios := make([]float64, 0)
+	vessels := make(map[string]int)
+	for _, entry := range best {
+		if entry.SteepTime > 0 {
+			times = append(times, float64(entry.SteepTime))
+		}
+		if entry.SteepingTemperature > 0 {
+			temps = append(temps, float64(entry.SteepingTemperature))
+		}
+		if ratio := entry.Ratio(); ratio > 0 {
+			ratios = append(ratios, ratio)
+		}
+		vessels[entry.VesselName()]++
+		if vessels[entry.VesselName()] > vessels[r.Vessel] || (vessels[entry.VesselName()] == vessels[r.Vessel] && entry.VesselName() < r.Vessel) {
+			r.Vessel = entry.VesselName()
+		}
+	}
+
+	r.SteepTime = time.Duration(median(times)).Round(time.Second)
+	r.Temperature = int(median(temps))
+	r.Ratio = median(ratios)
+
+	return r, true
+}
+
+func median(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+
+	sort.Float64s(values)
+	if (len(values) % 2) == 0 {
+		return (values[len(values)/2] + values[(len(values)/2)-1]) / 2
+	}
+	return values[len(values)/2]
+}
@@ -0,0 +1,55 @@
+package hgtealib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTeaRecommendation(t *testing.T) {
+	if _, ok := (&Tea{}).Recommendation(); ok {
+		t.Error("Found a recommendation for a tea without entries")
+	}
+
+	now := time.Now()
+	var tea Tea
+	tea.Add(Entry{DateTime: now, Rating: 2, SteepTime: time.Minute, SteepingTemperature: 212, SteepingVessel: Cup})
+	tea.Add(Entry{DateTime: now.Add(time.Hour), Rating: 4, SteepTime: 2 * time.Minute, SteepingTemperature: 190, SteepingVessel: Gaiwan, LeafGrams: 6, WaterMl: 100})
+	tea.Add(Entry{DateTime: now.Add(2 * time.Hour), Rating: 4, SteepTime: 4 * time.Minute, SteepingTemperature: 200, SteepingVessel: Gaiwan, LeafGrams: 4, WaterMl: 100})
+
+	r, ok := tea.Recommendation()
+	if !ok {
+		t.Fatal("Did not find a recommendation")
+	}
+
+	if r.Rating != 4 || r.Entries != 2 {
+		t.Errorf("Expected recommendation from 2 entries rated 4 but found %d rated %d", r.Entries, r.Rating)
+	}
+	if r.SteepTime != 3*time.Minute {
+		t.Errorf("Expected steep time of 3m but found %s", r.SteepTime)
+	}
+	if r.Temperature != 195 {
+		t.Errorf("Expected temperature of 195 but found %d", r.Temperature)
+	}
+	if r.Ratio != 5 {
+		t.Errorf("Expected ratio of 5 but found %f", r.Ratio)
+	}
+	if r.Vessel != Gaiwan.String() {
+		t.Errorf("Expected vessel %s but found %s", Gaiwan, r.Vessel)
+	}
+}
+
+func TestMedian(t *testing.T) {
+	for _, test := range []struct {
+		values []float64
+		median float64
+	}{
+		{nil, 0},
+		{[]float64{3}, 3},
+		{[]float64{5, 1, 3}, 3},
+		{[]float64{4, 1, 3, 2}, 2.5},
+	} {
+		if m := median(test.values); m != test.median {
+			t.Errorf("Expected median %f of %v but found %f", test.median, test.values, m)
+		}
+	}
+}
@@ -0,0 +1,394 @@
+package hgtealib
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// SearchHit is a tea, or a journal entry when Entry is set, which matched a search. The Snippet is taken from the
+// best matching field, and the Highlights are the byte ranges of the matched words within it.
+type SearchHit struct {
+	Tea        int
+	Entry      *Entry
+	Field      string
+	Score      float64
+	Snippet    string
+	Highlights [][2]int
+}
+
+// SnippetWords is the number of words of a field that a snippet is cut down to
+var SnippetWords = 16
+
+// searchStopWords are left out of a query, unless it is made up of nothing else
+var searchStopWords = map[string]struct{}{
+	"a": {}, "an": {}, "and": {}, "are": {}, "as": {}, "at": {}, "but": {}, "by": {}, "for": {}, "in": {}, "is": {},
+	"it": {}, "of": {}, "on": {}, "or": {}, "the": {}, "this": {}, "to": {}, "was": {}, "with": {},
+}
+
+// searchField is a field of a tea or entry which is indexed. Matches in fields with a greater weight rank higher.
+type searchField struct {
+	name   string
+	weight float64
+}
+
+var teaSearchFields = []searchField{
+	{"Name", 3},
+	{"Type", 2},
+	{"Origin", 2},
+	{"Purchase Location", 1},
+	{"Comments", 1},
+}
+
+var entrySearchFields = []searchField{
+	{"Comments", 1},
+}
+
+func teaSearchValue(tea Tea, field string) string {
+	switch {
+	case field == "Name":
+		return tea.String()
+	case field == "Type":
+		return tea.Type
+	case field == "Origin":
+		return tea.Origin.String()
+	case field == "Purchase Location":
+		return tea.Purchased.Location
+	case field == "Comments":
+		return tea.Comments
+	}
+	return ""
+}
+
+// word is a word of a field, with its position in the text of the field
+type word struct {
+	text       string
+	start, end int
+}
+
+func splitWords(s string) []word {
+	words := make([]word, 0)
+	start := -1
+	for i, r := range s {
+		letter := unicode.IsLetter(r) || unicode.IsNumber(r)
+		switch {
+		case letter && start < 0:
+			start = i
+		case !letter && start >= 0:
+			words = append(words, word{strings.ToLower(s[start:i]), start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, word{strings.ToLower(s[start:]), start, len(s)})
+	}
+	return words
+}
+
+func undouble(w string) string {
+	n := len(w)
+	if n > 2 && w[n-1] == w[n-2] && !strings.ContainsRune("aeioulsz", rune(w[n-1])) {
+		return w[:n-1]
+	}
+	return w
+}
+
+// stem reduces a lowercase word to a common root so that different forms of it match, i.e.: smoke, smoky and
+// smoked are all "smok"
+func stem(w string) string {
+	if utf8.RuneCountInString(w) <= 3 {
+		return w
+	}
+
+	switch {
+	case strings.HasSuffix(w, "ies") || strings.HasSuffix(w, "ied"):
+		w = w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "sses") || strings.HasSuffix(w, "ches") || strings.HasSuffix(w, "shes") || strings.HasSuffix(w, "xes"):
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") && !strings.HasSuffix(w, "us") && !strings.HasSuffix(w, "is"):
+		w = w[:len(w)-1]
+	}
+
+	for _, suffix := range []string{"ness", "ing", "ed", "ly"} {
+		if strings.HasSuffix(w, suffix) && len(w)-len(suffix) >= 3 {
+			w = w[:len(w)-len(suffix)]
+			if suffix == "ing" || suffix == "ed" {
+				w = undouble(w)
+			}
+			break
+		}
+	}
+
+	if strings.HasSuffix(w, "y") && len(w) > 4 {
+		w = w[:len(w)-1]
+	}
+	if strings.HasSuffix(w, "e") && len(w) > 3 {
+		w = w[:len(w)-1]
+	}
+
+	return w
+}
+
+type searchDocument struct {
+	tea    int
+	entry  *Entry
+	fields []searchField
+	values []string
+}
+
+type posting struct {
+	doc       int
+	field     int
+	positions []int
+}
+
+// SearchIndex is an inverted index of the stemmed words of the teas and journal entries
+type SearchIndex struct {
+	docs     []searchDocument
+	postings map[string][]posting
+}
+
+func (idx *SearchIndex) add(doc searchDocument) {
+	id := len(idx.docs)
+	idx.docs = append(idx.docs, doc)
+
+	for f, value := range doc.values {
+		positions := make(map[string][]int)
+		for i, w := range splitWords(value) {
+			term := stem(w.text)
+			positions[term] = append(positions[term], i)
+		}
+		for term, p := range positions {
+			idx.postings[term] = append(idx.postings[term], posting{id, f, p})
+		}
+	}
+}
+
+func NewSearchIndex(teas map[int]Tea, log []Entry) *SearchIndex {
+	idx := &SearchIndex{postings: make(map[string][]posting)}
+
+	ids := make([]int, 0)
+	for id := range teas {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	for _, id := range ids {
+		doc := searchDocument{tea: id, fields: teaSearchFields}
+		for _, f := range teaSearchFields {
+			doc.values = append(doc.values, teaSearchValue(teas[id], f.name))
+		}
+		idx.add(doc)
+	}
+
+	for i := range log {
+		idx.add(searchDocument{tea: log[i].Tea, entry: &log[i], fields: entrySearchFields, values: []string{log[i].Comments}})
+	}
+
+	return idx
+}
+
+func queryTerms(query string) []string {
+	terms := make([]string, 0)
+	stopWords := make([]string, 0)
+	for _, w := range splitWords(query) {
+		if _, ok := searchStopWords[w.text]; ok {
+			stopWords = append(stopWords, stem(w.text))
+		} else {
+			terms = append(terms, stem(w.text))
+		}
+	}
+	if len(terms) == 0 {
+		return stopWords
+	}
+	return terms
+}
+
+// isPhrase returns true if the terms appear one after another in the field
+func isPhrase(positions [][]int) bool {
+	for _, start := range positions[0] {
+		found := true
+		for i := 1; i < len(positions) && found; i++ {
+			found = false
+			for _, p := range positions[i] {
+				if p == start+i {
+					found = true
+					break
+				}
+			}
+		}
+		if found {
+			return true
+		}
+	}
+	return false
+}
+
+// Search returns the teas and entries which contain every word of the query, ranked by how often the words occur in
+// them weighed by how rare the words are, with matches of the whole query as a phrase ranked above the rest
+func (idx *SearchIndex) Search(query string) []SearchHit {
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return []SearchHit{}
+	}
+
+	// The positions of each term, by document and field
+	matches := make(map[int]map[int][][]int)
+	for t, term := range terms {
+		found := make(map[int]bool)
+		for _, p := range idx.postings[term] {
+			if t > 0 && matches[p.doc] == nil {
+				continue
+			}
+			if matches[p.doc] == nil {
+				matches[p.doc] = make(map[int][][]int)
+			}
+			if matches[p.doc][p.field] == nil {
+				matches[p.doc][p.field] = make([][]int, len(terms))
+			}
+			matches[p.doc][p.field][t] = p.positions
+			found[p.doc] = true
+		}
+		for doc := range matches {
+			if !found[doc] {
+				delete(matches, doc)
+			}
+		}
+	}
+
+	hits := make([]SearchHit, 0)
+	for doc, fields := range matches {
+		d := idx.docs[doc]
+		hit := SearchHit{Tea: d.tea, Entry: d.entry}
+
+		best, bestScore := -1, 0.0
+		for f, positions := range fields {
+			var score float64
+			complete := true
+			for t, p := range positions {
+				if p == nil {
+					complete = false
+					continue
+				}
+				idf := math.Log(1 + float64(len(idx.docs))/float64(len(idx.postings[terms[t]])))
+				score += float64(len(p)) * idf * d.fields[f].weight
+			}
+			if complete && len(terms) > 1 && isPhrase(positions) {
+				score *= 2
+			}
+			hit.Score += score
+			if score > bestScore || (score == bestScore && f < best) {
+				best, bestScore = f, score
+			}
+		}
+
+		hit.Field = d.fields[best].name
+		hit.Snippet, hit.Highlights = snippet(d.values[best], terms)
+		hits = append(hits, hit)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+		if (hits[i].Entry == nil) != (hits[j].Entry == nil) {
+			return hits[i].Entry == nil
+		}
+		if hits[i].Entry != nil {
+			return hits[i].Entry.DateTime.After(hits[j].Entry.DateTime)
+		}
+		return hits[i].Tea < hits[j].Tea
+	})
+
+	return hits
+}
+
+// snippet cuts the text down to the words around the first match of any of the terms, returning the ranges of the
+// matching words within the snippet. Whitespace is replaced with spaces so that the snippet is a single line.
+func snippet(text string, terms []string) (string, [][2]int) {
+	words := splitWords(text)
+	matched := make([]bool, len(words))
+	first := -1
+	for i, w := range words {
+		term := stem(w.text)
+		for _, t := range terms {
+			if term == t {
+				matched[i] = true
+				if first < 0 {
+					first = i
+				}
+				break
+			}
+		}
+	}
+
+	if first < 0 {
+		first = 0
+	}
+
+	start, end := 0, len(words)
+	if len(words) > SnippetWords {
+		start = first - SnippetWords/4
+		if start < 0 {
+			start = 0
+		}
+		end = start + SnippetWords
+		if end > len(words) {
+			end = len(words)
+			start = end - SnippetWords
+		}
+	}
+
+	var prefix, suffix string
+	from := len(text) - len(strings.TrimLeftFunc(text, unicode.IsSpace))
+	to := len(strings.TrimRightFunc(text, unicode.IsSpace))
+	if start > 0 {
+		prefix = "…"
+		from = words[start].start
+	}
+	if end < len(words) {
+		suffix = "…"
+		to = words[end-1].end
+	}
+
+	highlights := make([][2]int, 0)
+	for i := start; i < end; i++ {
+		if matched[i] {
+			offset := len(prefix) - from
+			highlights = append(highlights, [2]int{words[i].start + offset, words[i].end + offset})
+		}
+	}
+
+	s := strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\r' || r == '\n' {
+			return ' '
+		}
+		return r
+	}, text[from:to])
+	return prefix + s + suffix, highlights
+}
+
+// Search returns the teas and journal entries which match the query, limited to those which match the filter the
+// same way as Teas and Log
+func (d *TeaDb) Search(query string, filter *Filter) ([]SearchHit, error) {
+	if d.index == nil {
+		log, err := d.Log(NewFilter())
+		if err != nil {
+			return nil, err
+		}
+		d.index = NewSearchIndex(d.teas, log)
+	}
+
+	hits := make([]SearchHit, 0)
+	for _, hit := range d.index.Search(query) {
+		if !filter.matches(d.teas[hit.Tea]) {
+			continue
+		}
+		if hit.Entry != nil && !filter.matchesEntry(*hit.Entry) {
+			continue
+		}
+		hits = append(hits, hit)
+	}
+	return hits, nil
+}
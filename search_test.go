@@ -0,0 +1,116 @@
+package hgtealib
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStem(t *testing.T) {
+	tests := map[string]string{
+		"smoke":    "smok",
+		"smoky":    "smok",
+		"smoked":   "smok",
+		"smoking":  "smok",
+		"fruits":   "fruit",
+		"fruity":   "fruit",
+		"peaches":  "peach",
+		"cherries": "cherr",
+		"cherry":   "cherr",
+		"stopping": "stop",
+		"grassy":   "grass",
+		"tea":      "tea",
+	}
+
+	for word, expected := range tests {
+		if s := stem(word); s != expected {
+			t.Errorf("Stemmed '%s' to '%s' instead of expected: %s", word, s, expected)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	text, highlights := snippet("Notes of stone\nfruit", []string{stem("stone"), stem("fruit")})
+	if text != "Notes of stone fruit" {
+		t.Errorf("Unexpected snippet: %q", text)
+	}
+	if len(highlights) != 2 || text[highlights[0][0]:highlights[0][1]] != "stone" || text[highlights[1][0]:highlights[1][1]] != "fruit" {
+		t.Errorf("Unexpected highlights: %v", highlights)
+	}
+
+	long := strings.Repeat("filler ", 20) + "apricot " + strings.Repeat("filler ", 20)
+	text, highlights = snippet(long, []string{stem("apricot")})
+	if !strings.HasPrefix(text, "…") || !strings.HasSuffix(text, "…") {
+		t.Errorf("Long snippet was not cut down: %q", text)
+	}
+	if len(strings.Fields(text)) != SnippetWords {
+		t.Errorf("Expected %d words in the snippet but found %d: %q", SnippetWords, len(strings.Fields(text)), text)
+	}
+	if len(highlights) != 1 || text[highlights[0][0]:highlights[0][1]] != "apricot" {
+		t.Errorf("Unexpected highlights: %v", highlights)
+	}
+}
+
+func TestSearchIndex(t *testing.T) {
+	teas := map[int]Tea{
+		1: {Id: 1, Name: "Dong Ding", Type: "Oolong", Comments: "Roasted stone fruit"},
+		2: {Id: 2, Name: "Bai Mu Dan", Type: "White"},
+	}
+	now := time.Now()
+	log := []Entry{
+		{Tea: 2, DateTime: now.Add(-time.Hour), Comments: "fruit and stone"},
+		{Tea: 2, DateTime: now, Comments: "Notes of stone fruits"},
+		{Tea: 1, DateTime: now.Add(-2 * time.Hour), Comments: "smooth honey"},
+	}
+	idx := NewSearchIndex(teas, log)
+
+	hits := idx.Search("stone fruit")
+	if len(hits) != 3 {
+		t.Fatalf("Expected 3 hits but found %d: %v", len(hits), hits)
+	}
+
+	// The phrase matches rank above the words out of order
+	if hits[2].Entry == nil || hits[2].Entry.Comments != "fruit and stone" {
+		t.Errorf("Expected the match out of order to rank last: %v", hits)
+	}
+	if hits[0].Entry != nil || hits[0].Tea != 1 || hits[0].Field != "Comments" {
+		t.Errorf("Expected the tea to rank first: %v", hits[0])
+	}
+
+	if hits := idx.Search("stone honey"); len(hits) != 0 {
+		t.Errorf("Expected every word to be required: %v", hits)
+	}
+
+	if hits := idx.Search("oolong"); len(hits) != 1 || hits[0].Tea != 1 || hits[0].Field != "Type" {
+		t.Errorf("Unexpected hits on the tea type: %v", hits)
+	}
+
+	if hits := idx.Search("the"); len(hits) != 0 {
+		t.Errorf("Unexpected hits on a stop word: %v", hits)
+	}
+}
+
+func TestTeaDbSearch(t *testing.T) {
+	db, err := newTeaDb(testTeas, testEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	hits, err := db.Search("comments", NewFilter())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(hits) != 1 || hits[0].Entry == nil || hits[0].Tea != 42 {
+		t.Errorf("Unexpected hits: %v", hits)
+	}
+
+	hits, _ = db.Search("comments", NewFilter().Type("Green"))
+	if len(hits) != 0 {
+		t.Errorf("Hits were not filtered by tea type: %v", hits)
+	}
+
+	hits, _ = db.Search("test tea", NewFilter())
+	if len(hits) != 2 {
+		t.Errorf("Expected a hit on each tea name: %v", hits)
+	}
+}
@@ -0,0 +1,33 @@
+package hgtealib
+
+type Spend struct {
+	Teas   int
+	Totals map[string]float64
+}
+
+// Spend groups the filtered teas and totals their purchase prices by currency
+func (d *TeaDb) Spend(filter *Filter, group TeaGrouping) (map[string]Spend, error) {
+	teas, err := d.Teas(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	spend := make(map[string]Spend)
+	for _, tea := range teas {
+		if tea.Purchased.Price == 0 {
+			continue
+		}
+
+		for _, g := range group(tea) {
+			s, ok := spend[g]
+			if !ok {
+				s.Totals = make(map[string]float64)
+			}
+			s.Teas++
+			s.Totals[tea.Purchased.Currency] += tea.Purchased.Price
+			spend[g] = s
+		}
+	}
+
+	return spend, nil
+}
@@ -0,0 +1,49 @@
+package hgtealib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTeaDbSpend(t *testing.T) {
+	teas := []*Tea{
+		{Id: 1, Type: "Oolong", Purchased: TeaPurchaseInfo{Location: "A", Price: 10, Currency: "USD", Date: time.Date(2016, 4, 1, 0, 0, 0, 0, time.UTC)}},
+		{Id: 2, Type: "Oolong", Purchased: TeaPurchaseInfo{Location: "B", Price: 5.5, Currency: "USD", Date: time.Date(2016, 4, 20, 0, 0, 0, 0, time.UTC)}},
+		{Id: 3, Type: "Black", Purchased: TeaPurchaseInfo{Location: "A", Price: 8, Currency: "EUR", Date: time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC)}},
+		{Id: 4, Type: "Black"},
+	}
+
+	db, err := newTeaDb(teas, []*Entry{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spend, err := db.Spend(NewFilter(), TeaGroupings["Type"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if spend["Oolong"].Teas != 2 || spend["Oolong"].Totals["USD"] != 15.5 {
+		t.Errorf("Unexpected oolong spend: %+v", spend["Oolong"])
+	}
+
+	if spend["Black"].Teas != 1 || spend["Black"].Totals["EUR"] != 8 || len(spend["Black"].Totals) != 1 {
+		t.Errorf("Unexpected black spend: %+v", spend["Black"])
+	}
+
+	if spend, err = db.Spend(NewFilter(), TeaGroupings["Purchase Month"]); err != nil {
+		t.Fatal(err)
+	}
+
+	if spend["2016-04"].Teas != 2 || spend["2017-01"].Teas != 1 {
+		t.Errorf("Unexpected monthly spend: %+v", spend)
+	}
+
+	if spend, err = db.Spend(NewFilter(), TeaGroupings["Vendor"]); err != nil {
+		t.Fatal(err)
+	}
+
+	if spend["A"].Totals["USD"] != 10 || spend["A"].Totals["EUR"] != 8 {
+		t.Errorf("Unexpected vendor spend: %+v", spend["A"])
+	}
+}
@@ -0,0 +1,632 @@
+package hgtealib
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// SqliteDriver is the name of the database/sql driver that sqlite databases are opened with
+var SqliteDriver = "sqlite"
+
+// SqliteBusyTimeout is how long a change to a sqlite database waits for another process to finish its own
+var SqliteBusyTimeout = 10 * time.Second
+
+const sqliteTimeLayout = "2006-01-02T15:04:05.999999999Z07:00"
+
+// sqliteTime formats the time of an entry as its key, which is in UTC so that the same time in another zone has the
+// same key and the keys sort in order
+func sqliteTime(t time.Time) string {
+	return t.UTC().Format(sqliteTimeLayout)
+}
+
+// sqliteMigrations are applied in order to bring a database up to the current schema. The number of migrations that
+// a database has had applied is kept in its user_version.
+var sqliteMigrations = []string{
+	`CREATE TABLE vessels (
+		id     INTEGER PRIMARY KEY,
+		name   TEXT NOT NULL,
+		volume INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE TABLE fixins (
+		id       INTEGER PRIMARY KEY,
+		name     TEXT NOT NULL,
+		category TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE teas (
+		id                INTEGER PRIMARY KEY,
+		name              TEXT NOT NULL,
+		type              TEXT NOT NULL DEFAULT '',
+		year              INTEGER NOT NULL DEFAULT 0,
+		flush             REAL NOT NULL DEFAULT 0,
+		flush_naming      INTEGER NOT NULL DEFAULT 0,
+		country           TEXT NOT NULL DEFAULT '',
+		region            TEXT NOT NULL DEFAULT '',
+		stocked           INTEGER NOT NULL DEFAULT 0,
+		aging             INTEGER NOT NULL DEFAULT 0,
+		purchase_location TEXT NOT NULL DEFAULT '',
+		purchase_date     TEXT,
+		price             REAL NOT NULL DEFAULT 0,
+		currency          TEXT NOT NULL DEFAULT '',
+		packaging         INTEGER NOT NULL DEFAULT 0,
+		size              TEXT NOT NULL DEFAULT '',
+		quantity          REAL NOT NULL DEFAULT 0,
+		leaf_per_session  REAL NOT NULL DEFAULT 0,
+		leaf_grade        TEXT NOT NULL DEFAULT '',
+		comments          TEXT NOT NULL DEFAULT ''
+	);
+	CREATE TABLE tea_ratings (
+		tea    INTEGER NOT NULL REFERENCES teas(id) ON DELETE CASCADE,
+		name   TEXT NOT NULL,
+		rating INTEGER NOT NULL,
+		PRIMARY KEY (tea, name)
+	);
+	CREATE TABLE tea_blends (
+		blend    INTEGER NOT NULL REFERENCES teas(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		tea      INTEGER NOT NULL REFERENCES teas(id),
+		ratio    REAL NOT NULL,
+		PRIMARY KEY (blend, position)
+	);
+	CREATE TABLE sessions (
+		id  TEXT PRIMARY KEY,
+		tea INTEGER NOT NULL REFERENCES teas(id)
+	);
+	CREATE TABLE entries (
+		time         TEXT PRIMARY KEY,
+		tea          INTEGER NOT NULL REFERENCES teas(id),
+		rating       INTEGER NOT NULL DEFAULT 0,
+		comments     TEXT NOT NULL DEFAULT '',
+		steep_time   REAL NOT NULL DEFAULT 0,
+		vessel_type  INTEGER NOT NULL REFERENCES vessels(id),
+		vessel       TEXT NOT NULL DEFAULT '',
+		temperature  INTEGER NOT NULL DEFAULT 0,
+		session      TEXT REFERENCES sessions(id),
+		leaf_grams   REAL NOT NULL DEFAULT 0,
+		water_ml     INTEGER NOT NULL DEFAULT 0
+	);
+	CREATE INDEX entries_tea ON entries(tea);
+	CREATE INDEX entries_session ON entries(session);
+	CREATE TABLE entry_fixins (
+		entry    TEXT NOT NULL REFERENCES entries(time) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		fixin    INTEGER NOT NULL REFERENCES fixins(id),
+		PRIMARY KEY (entry, position)
+	);
+	CREATE VIEW journal AS
+		SELECT e.time, t.name AS tea, e.rating, e.steep_time, v.name AS vessel_type, e.vessel, e.temperature,
+			(SELECT group_concat(f.name, ';') FROM entry_fixins ef JOIN fixins f ON f.id = ef.fixin WHERE ef.entry = e.time) AS fixins,
+			e.session, e.leaf_grams, e.water_ml, e.comments
+		FROM entries e JOIN teas t ON t.id = e.tea JOIN vessels v ON v.id = e.vessel_type;`,
+}
+
+func openSqlite(path string) (*sql.DB, error) {
+	db, err := sql.Open(SqliteDriver, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// A single connection keeps the pragmas, which only hold for the connection that they were set on
+	db.SetMaxOpenConns(1)
+	for _, pragma := range []string{"PRAGMA foreign_keys = ON", fmt.Sprintf("PRAGMA busy_timeout = %d", SqliteBusyTimeout/time.Millisecond)} {
+		if _, err := db.Exec(pragma); err != nil {
+			db.Close()
+			return nil, errors.New(fmt.Sprintf("Unable to open sqlite database '%s': %s", path, err))
+		}
+	}
+
+	return db, nil
+}
+
+// migrateSqlite applies the migrations that the database does not have yet
+func migrateSqlite(db *sql.DB) error {
+	var version int
+	if err := db.QueryRow("PRAGMA user_version").Scan(&version); err != nil {
+		return err
+	}
+	if version > len(sqliteMigrations) {
+		return errors.New(fmt.Sprintf("Database schema version %d is newer than the supported version: %d", version, len(sqliteMigrations)))
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return errors.New(fmt.Sprintf("Migration %d failed: %s", i+1, err))
+		}
+		if _, err := tx.Exec(fmt.Sprintf("PRAGMA user_version = %d", i+1)); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// syncSqliteLookups writes the known vessel types and fixins to the database, and then adds the ones that only the
+// database knows of
+func syncSqliteLookups(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, v := range VesselTypes {
+		if _, err := tx.Exec("INSERT INTO vessels (id, name, volume) VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET name = excluded.name, volume = excluded.volume", int(v.Id), v.Name, v.Volume); err != nil {
+			return err
+		}
+	}
+	for _, f := range TeaFixins {
+		if _, err := tx.Exec("INSERT INTO fixins (id, name, category) VALUES (?, ?, ?) ON CONFLICT (id) DO UPDATE SET name = excluded.name, category = excluded.category", int(f.Id), f.Name, f.Category.String()); err != nil {
+			return err
+		}
+	}
+
+	vessels := make([]VesselTypeDefinition, 0)
+	rows, err := tx.Query("SELECT id, name, volume FROM vessels")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var v VesselTypeDefinition
+		if err := rows.Scan(&v.Id, &v.Name, &v.Volume); err != nil {
+			rows.Close()
+			return err
+		}
+		vessels = append(vessels, v)
+	}
+	rows.Close()
+
+	fixins := make([]FixinDefinition, 0)
+	rows, err = tx.Query("SELECT id, name, category FROM fixins")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var f FixinDefinition
+		var category string
+		if err := rows.Scan(&f.Id, &f.Name, &category); err != nil {
+			rows.Close()
+			return err
+		}
+		if f.Category, err = ParseFixinCategory(category); err != nil {
+			rows.Close()
+			return err
+		}
+		fixins = append(fixins, f)
+	}
+	rows.Close()
+
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, v := range vessels {
+		if _, ok := VesselTypes[v.Id]; !ok {
+			if err := AddVesselType(v); err != nil {
+				return err
+			}
+		}
+	}
+	for _, f := range fixins {
+		if _, ok := TeaFixins[f.Id]; !ok {
+			if err := AddTeaFixin(f); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func putSqliteTea(tx *sql.Tx, tea Tea) error {
+	var purchased interface{}
+	if date := tea.Purchased.DateString(); date != "" {
+		purchased = date
+	}
+
+	_, err := tx.Exec(`INSERT INTO teas (id, name, type, year, flush, flush_naming, country, region, stocked, aging,
+			purchase_location, purchase_date, price, currency, packaging, size, quantity, leaf_per_session, leaf_grade, comments)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name, type = excluded.type, year = excluded.year,
+			flush = excluded.flush, flush_naming = excluded.flush_naming, country = excluded.country,
+			region = excluded.region, stocked = excluded.stocked, aging = excluded.aging,
+			purchase_location = excluded.purchase_location, purchase_date = excluded.purchase_date,
+			price = excluded.price, currency = excluded.currency, packaging = excluded.packaging, size = excluded.size,
+			quantity = excluded.quantity, leaf_per_session = excluded.leaf_per_session,
+			leaf_grade = excluded.leaf_grade, comments = excluded.comments`,
+		tea.Id, tea.Name, tea.Type, tea.Picked.Year, float64(tea.Picked.Flush.Flush), int(tea.Picked.Flush.Naming),
+		tea.Origin.Country, tea.Origin.Region, tea.Storage.Stocked, tea.Storage.Aging, tea.Purchased.Location, purchased,
+		tea.Purchased.Price, tea.Purchased.Currency, int(tea.Purchased.Packaging), tea.Size, tea.Quantity,
+		tea.LeafPerSession, tea.LeafGrade.Raw, tea.Comments)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM tea_ratings WHERE tea = ?", tea.Id); err != nil {
+		return err
+	}
+	for name, rating := range tea.Ratings {
+		if _, err := tx.Exec("INSERT INTO tea_ratings (tea, name, rating) VALUES (?, ?, ?)", tea.Id, name, rating); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM tea_blends WHERE blend = ?", tea.Id); err != nil {
+		return err
+	}
+	for i, c := range tea.Blend {
+		if _, err := tx.Exec("INSERT INTO tea_blends (blend, position, tea, ratio) VALUES (?, ?, ?, ?)", tea.Id, i, c.Tea, c.Ratio); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func deleteSqliteSessions(tx *sql.Tx) error {
+	_, err := tx.Exec("DELETE FROM sessions WHERE id NOT IN (SELECT session FROM entries WHERE session IS NOT NULL)")
+	return err
+}
+
+func putSqliteEntry(tx *sql.Tx, entry Entry) error {
+	at := sqliteTime(entry.DateTime)
+
+	var session interface{}
+	if entry.SessionInstance != "" {
+		session = entry.SessionInstance
+		if _, err := tx.Exec("INSERT INTO sessions (id, tea) VALUES (?, ?) ON CONFLICT (id) DO NOTHING", entry.SessionInstance, entry.Tea); err != nil {
+			return err
+		}
+	}
+
+	_, err := tx.Exec(`INSERT INTO entries (time, tea, rating, comments, steep_time, vessel_type, vessel, temperature,
+			session, leaf_grams, water_ml)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (time) DO UPDATE SET tea = excluded.tea, rating = excluded.rating, comments = excluded.comments,
+			steep_time = excluded.steep_time, vessel_type = excluded.vessel_type, vessel = excluded.vessel,
+			temperature = excluded.temperature, session = excluded.session, leaf_grams = excluded.leaf_grams,
+			water_ml = excluded.water_ml`,
+		at, entry.Tea, entry.Rating, entry.Comments, entry.SteepTime.Seconds(), int(entry.SteepingVessel), entry.Vessel,
+		entry.SteepingTemperature, session, entry.LeafGrams, entry.WaterMl)
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec("DELETE FROM entry_fixins WHERE entry = ?", at); err != nil {
+		return err
+	}
+	for i, f := range entry.Fixins {
+		if _, err := tx.Exec("INSERT INTO entry_fixins (entry, position, fixin) VALUES (?, ?, ?)", at, i, int(f)); err != nil {
+			return err
+		}
+	}
+
+	return deleteSqliteSessions(tx)
+}
+
+// sqliteStore writes the changes to a TeaDb to a sqlite database, each in its own transaction
+type sqliteStore struct {
+	db *sql.DB
+}
+
+func (s sqliteStore) update(change func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := change(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+func (s sqliteStore) PutTea(tea Tea) error {
+	return s.update(func(tx *sql.Tx) error {
+		return putSqliteTea(tx, tea)
+	})
+}
+
+func (s sqliteStore) DeleteTea(id int) error {
+	return s.update(func(tx *sql.Tx) error {
+		_, err := tx.Exec("DELETE FROM teas WHERE id = ?", id)
+		return err
+	})
+}
+
+func (s sqliteStore) PutEntry(entry Entry) error {
+	return s.update(func(tx *sql.Tx) error {
+		return putSqliteEntry(tx, entry)
+	})
+}
+
+func (s sqliteStore) DeleteEntry(at time.Time) error {
+	return s.update(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("DELETE FROM entries WHERE time = ?", sqliteTime(at)); err != nil {
+			return err
+		}
+		return deleteSqliteSessions(tx)
+	})
+}
+
+func loadSqliteTeas(db *sql.DB) ([]*Tea, error) {
+	teas := make([]*Tea, 0)
+	byId := make(map[int]*Tea)
+
+	rows, err := db.Query(`SELECT id, name, type, year, flush, flush_naming, country, region, stocked, aging,
+		purchase_location, purchase_date, price, currency, packaging, size, quantity, leaf_per_session, leaf_grade, comments
+		FROM teas ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		t := new(Tea)
+		var flush float64
+		var naming, packaging int
+		var purchased sql.NullString
+		var grade string
+		if err := rows.Scan(&t.Id, &t.Name, &t.Type, &t.Picked.Year, &flush, &naming, &t.Origin.Country, &t.Origin.Region,
+			&t.Storage.Stocked, &t.Storage.Aging, &t.Purchased.Location, &purchased, &t.Purchased.Price,
+			&t.Purchased.Currency, &packaging, &t.Size, &t.Quantity, &t.LeafPerSession, &grade, &t.Comments); err != nil {
+			return nil, err
+		}
+		t.Picked.Flush = TeaFlush{Flush: Flush(flush), Naming: FlushNaming(naming)}
+		t.Purchased.Packaging = TeaPackagingType(packaging)
+		t.LeafGrade = ParseLeafGrade(grade)
+		if purchased.Valid {
+			// A purchase date which is not recognized is kept as it was entered
+			t.Purchased.ParseDate(purchased.String)
+		}
+
+		teas = append(teas, t)
+		byId[t.Id] = t
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	ratings, err := db.Query("SELECT tea, name, rating FROM tea_ratings")
+	if err != nil {
+		return nil, err
+	}
+	defer ratings.Close()
+	for ratings.Next() {
+		var id, rating int
+		var name string
+		if err := ratings.Scan(&id, &name, &rating); err != nil {
+			return nil, err
+		}
+		if t, ok := byId[id]; ok {
+			if t.Ratings == nil {
+				t.Ratings = make(map[string]int)
+			}
+			t.Ratings[name] = rating
+		}
+	}
+	if err := ratings.Err(); err != nil {
+		return nil, err
+	}
+
+	blends, err := db.Query("SELECT blend, tea, ratio FROM tea_blends ORDER BY blend, position")
+	if err != nil {
+		return nil, err
+	}
+	defer blends.Close()
+	for blends.Next() {
+		var id int
+		var c TeaBlendComponent
+		if err := blends.Scan(&id, &c.Tea, &c.Ratio); err != nil {
+			return nil, err
+		}
+		if t, ok := byId[id]; ok {
+			t.Blend = append(t.Blend, c)
+		}
+	}
+
+	return teas, blends.Err()
+}
+
+func loadSqliteEntries(db *sql.DB) ([]*Entry, error) {
+	entries := make([]*Entry, 0)
+	byTime := make(map[string]*Entry)
+
+	rows, err := db.Query(`SELECT time, tea, rating, comments, steep_time, vessel_type, vessel, temperature, session,
+		leaf_grams, water_ml FROM entries`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		e := new(Entry)
+		var at string
+		var steep float64
+		var vessel int
+		var session sql.NullString
+		if err := rows.Scan(&at, &e.Tea, &e.Rating, &e.Comments, &steep, &vessel, &e.Vessel, &e.SteepingTemperature,
+			&session, &e.LeafGrams, &e.WaterMl); err != nil {
+			return nil, err
+		}
+		// The times are kept in UTC, and loaded in the zone of the journal
+		if e.DateTime, err = time.Parse(sqliteTimeLayout, at); err != nil {
+			return nil, err
+		}
+		e.DateTime = e.DateTime.In(journalLocation())
+		e.SteepTime = time.Duration(steep * float64(time.Second))
+		e.SteepingVessel = VesselType(vessel)
+		e.SessionInstance = session.String
+		e.ParseTags()
+
+		entries = append(entries, e)
+		byTime[at] = e
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	fixins, err := db.Query("SELECT entry, fixin FROM entry_fixins ORDER BY entry, position")
+	if err != nil {
+		return nil, err
+	}
+	defer fixins.Close()
+	for fixins.Next() {
+		var at string
+		var fixin int
+		if err := fixins.Scan(&at, &fixin); err != nil {
+			return nil, err
+		}
+		if e, ok := byTime[at]; ok {
+			e.Fixins = append(e.Fixins, TeaFixin(fixin))
+		}
+	}
+
+	return entries, fixins.Err()
+}
+
+// NewFromSqlite loads the teas and journal from a sqlite database, which all changes to the database are written to.
+// The database is created if it does not exist, and is migrated to the current schema.
+func NewFromSqlite(path string) (*TeaDb, error) {
+	db, err := openSqlite(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrateSqlite(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+	if err := syncSqliteLookups(db); err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	teas, err := loadSqliteTeas(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	entries, err := loadSqliteEntries(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return NewTeaDb(sqliteStore{db}, teas, entries)
+}
+
+// ImportSqlite replaces the teas and journal of a sqlite database with those of another database, such as the one
+// loaded from the TSV sheets
+func ImportSqlite(path string, src *TeaDb) error {
+	db, err := openSqlite(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err := migrateSqlite(db); err != nil {
+		return err
+	}
+	if err := syncSqliteLookups(db); err != nil {
+		return err
+	}
+
+	teas, err := src.Teas(NewFilter())
+	if err != nil {
+		return err
+	}
+	ids := make([]int, 0, len(teas))
+	for id := range teas {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	log, err := src.Log(NewFilter())
+	if err != nil {
+		return err
+	}
+
+	return sqliteStore{db}.update(func(tx *sql.Tx) error {
+		// Blends can reference teas which are inserted after them
+		if _, err := tx.Exec("PRAGMA defer_foreign_keys = ON"); err != nil {
+			return err
+		}
+		for _, table := range []string{"entry_fixins", "entries", "sessions", "tea_blends", "tea_ratings", "teas"} {
+			if _, err := tx.Exec("DELETE FROM " + table); err != nil {
+				return err
+			}
+		}
+
+		for _, id := range ids {
+			if err := putSqliteTea(tx, teas[id]); err != nil {
+				return errors.New(fmt.Sprintf("Unable to import tea %d: %s", id, err))
+			}
+		}
+		for _, entry := range log {
+			if err := putSqliteEntry(tx, entry); err != nil {
+				return errors.New(fmt.Sprintf("Unable to import entry at %s: %s", entry.DateTime, err))
+			}
+		}
+		return nil
+	})
+}
+
+// QuerySqlite runs a read-only query against a sqlite database, returning the names of the columns and the values of
+// each row. Text is returned as strings rather than bytes.
+func QuerySqlite(path, query string, args ...interface{}) ([]string, [][]interface{}, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil, errors.New(fmt.Sprintf("Did not find sqlite database at: %s", path))
+	}
+
+	db, err := openSqlite(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer db.Close()
+
+	if _, err := db.Exec("PRAGMA query_only = ON"); err != nil {
+		return nil, nil, err
+	}
+
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	values := make([][]interface{}, 0)
+	for rows.Next() {
+		row := make([]interface{}, len(columns))
+		ptrs := make([]interface{}, len(columns))
+		for i := range row {
+			ptrs[i] = &row[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return nil, nil, err
+		}
+		for i, v := range row {
+			if b, ok := v.([]byte); ok {
+				row[i] = string(b)
+			}
+		}
+		values = append(values, row)
+	}
+
+	return columns, values, rows.Err()
+}
@@ -0,0 +1,4 @@
+package hgtealib
+
+// The pure Go sqlite driver keeps the library building without cgo
+import _ "modernc.org/sqlite"
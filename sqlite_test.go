@@ -0,0 +1,272 @@
+package hgtealib
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestSqlitePath(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "hgtealib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return filepath.Join(dir, "teas.db")
+}
+
+func TestNewFromSqlite(t *testing.T) {
+	path := newTestSqlitePath(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	db, err := NewFromSqlite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.ReadOnly() {
+		t.Error("Sqlite database is read-only")
+	}
+
+	oolong := Tea{
+		Id:        1,
+		Name:      "Dong Ding",
+		Type:      "Oolong",
+		Picked:    TeaPickPeriod{Year: 2017, Flush: TeaFlush{Flush: Second}},
+		Origin:    TeaOrigin{Country: "Taiwan", Region: "Nantou"},
+		Storage:   TeaStorageState{Stocked: true},
+		Purchased: TeaPurchaseInfo{Location: "Online", Date: time.Date(2018, 2, 3, 0, 0, 0, 0, time.UTC), Price: 12.5, Currency: "USD"},
+		LeafGrade: ParseLeafGrade("FTGFOP1"),
+		Ratings:   map[string]int{"Value": 3},
+		Comments:  "roasty",
+	}
+	oolong.ParseSize("50g")
+	if _, err := db.AddTea(oolong); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AddTea(Tea{Id: 2, Name: "House Blend", Blend: TeaBlend{{Tea: 1, Ratio: 2}}}); err != nil {
+		t.Fatal(err)
+	}
+
+	at := time.Date(2018, 3, 1, 8, 30, 0, 0, time.UTC)
+	entry := Entry{Tea: 1, DateTime: at, Rating: 3, Comments: "toasty #evening", SteepTime: 150 * time.Second, SteepingVessel: Gaiwan,
+		SteepingTemperature: 195, SessionInstance: "ABC", Fixins: []TeaFixin{Honey, Milk}, LeafGrams: 5.5, WaterMl: 100}
+	if err := db.AddEntry(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewFromSqlite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tea, err := reopened.Tea(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch {
+	case tea.Name != oolong.Name || tea.Type != oolong.Type || tea.Picked != oolong.Picked || tea.Origin != oolong.Origin:
+		t.Errorf("Reloaded tea does not match: %+v", tea)
+	case tea.Storage != oolong.Storage || !tea.Purchased.Date.Equal(oolong.Purchased.Date) || tea.Purchased.Price != 12.5:
+		t.Errorf("Reloaded tea does not match: %+v", tea)
+	case tea.Size != "50g" || tea.Quantity != 50 || tea.LeafGrade.Code() != "FTGFOP1" || tea.Ratings["Value"] != 3 || tea.LogLen() != 1:
+		t.Errorf("Reloaded tea does not match: %+v", tea)
+	}
+	if blend, _ := reopened.Tea(2); !blend.Blend.Equal(TeaBlend{{Tea: 1, Ratio: 2}}) {
+		t.Errorf("Reloaded blend does not match: %v", blend.Blend)
+	}
+
+	e, err := reopened.Entry(at)
+	if err != nil {
+		t.Fatal(err)
+	}
+	switch {
+	case e.Rating != 3 || e.Comments != entry.Comments || e.SteepTime != entry.SteepTime || e.SteepingVessel != Gaiwan:
+		t.Errorf("Reloaded entry does not match: %+v", e)
+	case e.SteepingTemperature != 195 || e.SessionInstance != "ABC" || e.LeafGrams != 5.5 || e.WaterMl != 100 || len(e.Tags) != 2:
+		t.Errorf("Reloaded entry does not match: %+v", e)
+	case len(e.Fixins) != 2 || e.Fixins[0] != Honey || e.Fixins[1] != Milk:
+		t.Errorf("Reloaded fixins do not match: %v", e.Fixins)
+	}
+
+	if err := reopened.DeleteEntry(at); err != nil {
+		t.Fatal(err)
+	}
+	if err := reopened.DeleteTea(2); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err = NewFromSqlite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if teas, _ := reopened.Teas(NewFilter()); len(teas) != 1 {
+		t.Errorf("Expected 1 tea but found %d", len(teas))
+	}
+	if log, _ := reopened.Log(NewFilter()); len(log) != 0 {
+		t.Errorf("Expected no entries but found %d", len(log))
+	}
+	if _, rows, err := QuerySqlite(path, "SELECT id FROM sessions"); err != nil || len(rows) != 0 {
+		t.Errorf("Expected the session to be deleted with its entry: %v %v", rows, err)
+	}
+}
+
+func TestSqliteEntryZones(t *testing.T) {
+	path := newTestSqlitePath(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	db, err := NewFromSqlite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AddTea(Tea{Id: 1, Name: "Dong Ding"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The same time in another zone is the same entry
+	at := time.Date(2018, 3, 1, 8, 30, 0, 0, time.FixedZone("EST", -5*60*60))
+	if err := db.AddEntry(Entry{Tea: 1, DateTime: at, Rating: 3, Fixins: []TeaFixin{Milk}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, rows, err := QuerySqlite(path, "SELECT time FROM entries"); err != nil || len(rows) != 1 || rows[0][0] != "2018-03-01T13:30:00Z" {
+		t.Errorf("Entry was not keyed by its time in UTC: %v %v", rows, err)
+	}
+	if _, rows, err := QuerySqlite(path, "SELECT entry FROM entry_fixins"); err != nil || len(rows) != 1 || rows[0][0] != "2018-03-01T13:30:00Z" {
+		t.Errorf("Fixins were not keyed by the time of the entry in UTC: %v %v", rows, err)
+	}
+
+	// The entry is loaded in the zone of the journal, so an evening session keeps its date
+	evening := time.Date(2018, 3, 1, 21, 30, 0, 0, time.FixedZone("EST", -5*60*60))
+	if err := db.AddEntry(Entry{Tea: 1, DateTime: evening, Rating: 2}); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := NewFromSqlite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	e, err := reopened.Entry(evening)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.DateTime.Location().String() != "America/New_York" || e.DateTime.Format("1/2/2006 1504") != "3/1/2018 2130" {
+		t.Errorf("Entry was loaded at %s instead of in the zone of the journal", e.DateTime)
+	}
+	if err := db.DeleteEntry(evening); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.store.DeleteEntry(at.UTC()); err != nil {
+		t.Fatal(err)
+	}
+	if _, rows, err := QuerySqlite(path, "SELECT time FROM entries"); err != nil || len(rows) != 0 {
+		t.Errorf("Entry was not deleted by its time in UTC: %v %v", rows, err)
+	}
+}
+
+func TestMigrateSqlite(t *testing.T) {
+	path := newTestSqlitePath(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	if _, err := NewFromSqlite(path); err != nil {
+		t.Fatal(err)
+	}
+	// Migrating again does nothing
+	if _, err := NewFromSqlite(path); err != nil {
+		t.Fatal(err)
+	}
+
+	_, rows, err := QuerySqlite(path, "PRAGMA user_version")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows[0][0].(int64) != int64(len(sqliteMigrations)) {
+		t.Errorf("Schema version %v does not match expected: %d", rows[0][0], len(sqliteMigrations))
+	}
+
+	db, err := openSqlite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Exec("PRAGMA user_version = 99"); err != nil {
+		t.Fatal(err)
+	}
+	db.Close()
+
+	if _, err := NewFromSqlite(path); err == nil {
+		t.Error("Did not receive expected error from a newer schema")
+	}
+}
+
+func TestImportSqlite(t *testing.T) {
+	path := newTestSqlitePath(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	teas := []*Tea{{Id: 1, Name: "Blend", Blend: TeaBlend{{Tea: 2, Ratio: 1}}}, {Id: 2, Name: "Dong Ding"}}
+	entries := []*Entry{
+		{Tea: 2, DateTime: time.Date(2018, 1, 1, 8, 0, 0, 0, time.UTC), Rating: 3, SessionInstance: "A"},
+		{Tea: 2, DateTime: time.Date(2018, 1, 1, 9, 0, 0, 0, time.UTC), Rating: 4, SessionInstance: "A"},
+		{Tea: 1, DateTime: time.Date(2018, 1, 2, 8, 0, 0, 0, time.UTC), Rating: 2, Fixins: []TeaFixin{Sugar}},
+	}
+	src, err := newTeaDb(teas, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Importing twice replaces the first import
+	for i := 0; i < 2; i++ {
+		if err := ImportSqlite(path, src); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	db, err := NewFromSqlite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if teas, _ := db.Teas(NewFilter()); len(teas) != 2 {
+		t.Errorf("Expected 2 teas but found %d", len(teas))
+	}
+	if log, _ := db.Log(NewFilter()); len(log) != 3 {
+		t.Errorf("Expected 3 entries but found %d", len(log))
+	}
+
+	columns, rows, err := QuerySqlite(path, "SELECT tea, count(*) AS entries, max(rating) FROM journal GROUP BY tea ORDER BY tea")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(columns) != 3 || columns[1] != "entries" || len(rows) != 2 {
+		t.Fatalf("Unexpected query results: %v %v", columns, rows)
+	}
+	if rows[1][0] != "Dong Ding" || rows[1][1].(int64) != 2 {
+		t.Errorf("Unexpected query results: %v", rows)
+	}
+	if _, rows, _ := QuerySqlite(path, "SELECT id FROM sessions"); len(rows) != 1 {
+		t.Errorf("Expected 1 session but found %d", len(rows))
+	}
+}
+
+func TestQuerySqlite(t *testing.T) {
+	path := newTestSqlitePath(t)
+	defer os.RemoveAll(filepath.Dir(path))
+
+	if _, _, err := QuerySqlite(path, "SELECT 1"); err == nil {
+		t.Error("Did not receive expected error from a missing database")
+	}
+
+	db, err := NewFromSqlite(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.AddTea(Tea{Name: "Sencha"}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := QuerySqlite(path, "DELETE FROM teas"); err == nil {
+		t.Error("Did not receive expected error when changing the database")
+	}
+	if _, rows, err := QuerySqlite(path, "SELECT name FROM teas WHERE id = ?", 1); err != nil || len(rows) != 1 || rows[0][0] != "Sencha" {
+		t.Errorf("Unexpected query results: %v %v", rows, err)
+	}
+	if _, _, err := QuerySqlite(path, "SELECT * FROM nope"); err == nil {
+		t.Error("Did not receive expected error from an invalid query")
+	}
+}
@@ -0,0 +1,144 @@
+package hgtealib
+
+import (
+	"sort"
+	"strconv"
+)
+
+type Stats struct {
+	Teas    int
+	Entries int
+	Average float64
+	Median  int
+	Mode    int
+	Ratio   float64
+	Ratings map[string]float64
+}
+
+func newStats(teas []Tea) Stats {
+	var s Stats
+	s.Teas = len(teas)
+	s.Ratings = make(map[string]float64)
+
+	ratings := make([]int, 0)
+	products := make(map[string]int)
+	var ratios int
+	for _, tea := range teas {
+		for _, entry := range tea.log {
+			ratings = append(ratings, entry.Rating)
+			if ratio := entry.Ratio(); ratio > 0 {
+				s.Ratio += ratio
+				ratios++
+			}
+		}
+
+		for name, rating := range tea.Ratings {
+			s.Ratings[name] += float64(rating)
+			products[name]++
+		}
+	}
+
+	for name, count := range products {
+		s.Ratings[name] /= float64(count)
+	}
+
+	if ratios > 0 {
+		s.Ratio /= float64(ratios)
+	}
+
+	s.Entries = len(ratings)
+	if s.Entries == 0 {
+		return s
+	}
+
+	sort.Ints(ratings)
+
+	counts := make(map[int]int)
+	var total int
+	for _, r := range ratings {
+		total += r
+		counts[r]++
+		if counts[r] > counts[s.Mode] {
+			s.Mode = r
+		}
+	}
+	s.Average = float64(total) / float64(s.Entries)
+
+	if (s.Entries % 2) == 0 {
+		s.Median = (ratings[s.Entries/2] + ratings[(s.Entries/2)-1]) / 2
+	} else {
+		s.Median = ratings[s.Entries/2]
+	}
+
+	return s
+}
+
+// TeaGrouping returns the names of the groups that a tea belongs to
+type TeaGrouping func(Tea) []string
+
+var TeaGroupings = map[string]TeaGrouping{
+	"Tea": func(t Tea) []string {
+		return []string{t.String()}
+	},
+	"Type": func(t Tea) []string {
+		return []string{TeaTypes.Canonical(t.Type)}
+	},
+	"Country": func(t Tea) []string {
+		return []string{t.Origin.Country}
+	},
+	"Origin": func(t Tea) []string {
+		return []string{t.Origin.String()}
+	},
+	"Year": func(t Tea) []string {
+		if t.Picked.Year == 0 {
+			return []string{""}
+		}
+		return []string{strconv.Itoa(t.Picked.Year)}
+	},
+	"Flush": func(t Tea) []string {
+		return []string{t.Picked.Flush.String()}
+	},
+	"Packaging": func(t Tea) []string {
+		return []string{t.Purchased.Packaging.String()}
+	},
+	"Grade": func(t Tea) []string {
+		return []string{t.LeafGrade.Code()}
+	},
+	"Leaf Style": func(t Tea) []string {
+		return []string{t.LeafGrade.Style.String()}
+	},
+	"Vendor": func(t Tea) []string {
+		return []string{t.Purchased.Location}
+	},
+	"Purchase Month": func(t Tea) []string {
+		return []string{t.Purchased.Month()}
+	},
+	"Purchase Year": func(t Tea) []string {
+		if t.Purchased.Date.IsZero() {
+			return []string{""}
+		}
+		return []string{strconv.Itoa(t.Purchased.Date.Year())}
+	},
+}
+
+// Stats groups the filtered teas and calculates the rating statistics of each group
+func (d *TeaDb) Stats(filter *Filter, group TeaGrouping) (map[string]Stats, error) {
+	teas, err := d.Teas(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make(map[string][]Tea)
+	for _, tea := range teas {
+		for _, g := range group(tea) {
+			groups[g] = append(groups[g], tea)
+		}
+	}
+
+	stats := make(map[string]Stats)
+	for g, members := range groups {
+		stats[g] = newStats(members)
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,78 @@
+package hgtealib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNewStats(t *testing.T) {
+	tea1 := Tea{Ratings: map[string]int{"Value": 4, "Leaf Aroma": 1}}
+	tea2 := Tea{Ratings: map[string]int{"Value": 2}}
+	for i, r := range []int{1, 3, 3} {
+		tea1.Add(Entry{DateTime: time.Unix(int64(i), 0), Rating: r})
+	}
+	tea2.Add(Entry{DateTime: time.Unix(0, 0), Rating: 4})
+
+	s := newStats([]Tea{tea1, tea2})
+
+	if s.Teas != 2 {
+		t.Errorf("Expected 2 teas but found %d", s.Teas)
+	}
+
+	if s.Entries != 4 {
+		t.Errorf("Expected 4 entries but found %d", s.Entries)
+	}
+
+	if s.Average != 2.75 {
+		t.Errorf("Expected average of 2.75 but found %f", s.Average)
+	}
+
+	if s.Median != 3 {
+		t.Errorf("Expected median of 3 but found %d", s.Median)
+	}
+
+	if s.Mode != 3 {
+		t.Errorf("Expected mode of 3 but found %d", s.Mode)
+	}
+
+	if s.Ratings["Value"] != 3 || s.Ratings["Leaf Aroma"] != 1 {
+		t.Errorf("Unexpected product rating averages: %v", s.Ratings)
+	}
+
+	if s = newStats([]Tea{}); s.Entries != 0 || s.Average != 0 {
+		t.Errorf("Unexpected stats for no teas: %+v", s)
+	}
+}
+
+func TestTeaDbStats(t *testing.T) {
+	db, err := newTeaDb(testTeas, testEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stats, err := db.Stats(NewFilter(), TeaGroupings["Type"])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	types := make(map[string]int)
+	for _, v := range testTeas {
+		types[v.Type]++
+	}
+
+	if len(stats) != len(types) {
+		t.Fatalf("Expected %d groups but found %d", len(types), len(stats))
+	}
+
+	for k, v := range types {
+		if stats[k].Teas != v {
+			t.Errorf("Expected %d teas of type %s but found %d", v, k, stats[k].Teas)
+		}
+	}
+
+	for name, group := range TeaGroupings {
+		if _, err := db.Stats(NewFilter(), group); err != nil {
+			t.Errorf("Could not group stats by %s: %s", name, err)
+		}
+	}
+}
@@ -0,0 +1,348 @@
+package hgtealib
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TeaStore is the backend that the changes to a TeaDb are written to. Entries are keyed by their time.
+type TeaStore interface {
+	PutTea(tea Tea) error
+	DeleteTea(id int) error
+	PutEntry(entry Entry) error
+	DeleteEntry(at time.Time) error
+}
+
+// ReadOnlyError is returned when changing a TeaDb whose backend cannot be written to
+type ReadOnlyError struct {
+	Backend string
+}
+
+func (e ReadOnlyError) Error() string {
+	return fmt.Sprintf("The %s database is read-only", e.Backend)
+}
+
+// readOnlyStore refuses every change, such as for the published Google sheets
+type readOnlyStore struct {
+	backend string
+}
+
+func (s readOnlyStore) PutTea(tea Tea) error {
+	return ReadOnlyError{s.backend}
+}
+
+func (s readOnlyStore) DeleteTea(id int) error {
+	return ReadOnlyError{s.backend}
+}
+
+func (s readOnlyStore) PutEntry(entry Entry) error {
+	return ReadOnlyError{s.backend}
+}
+
+func (s readOnlyStore) DeleteEntry(at time.Time) error {
+	return ReadOnlyError{s.backend}
+}
+
+// memoryStore keeps the changes in memory only
+type memoryStore struct{}
+
+func (memoryStore) PutTea(tea Tea) error {
+	return nil
+}
+
+func (memoryStore) DeleteTea(id int) error {
+	return nil
+}
+
+func (memoryStore) PutEntry(entry Entry) error {
+	return nil
+}
+
+func (memoryStore) DeleteEntry(at time.Time) error {
+	return nil
+}
+
+// NewTeaDb creates a database of the teas and entries whose changes are written to the store, or are only kept in
+// memory when the store is nil
+func NewTeaDb(store TeaStore, teas []*Tea, entries []*Entry) (*TeaDb, error) {
+	db, err := newTeaDb(teas, entries)
+	if err != nil {
+		return nil, err
+	}
+	if store != nil {
+		db.store = store
+	}
+	return db, nil
+}
+
+// ReadOnly returns true if the database cannot be changed
+func (d *TeaDb) ReadOnly() bool {
+	_, ok := d.store.(readOnlyStore)
+	return ok
+}
+
+func (d *TeaDb) validateTea(tea Tea) error {
+	switch {
+	case tea.Id <= 0:
+		return errors.New(fmt.Sprintf("Tea id must be positive: %d", tea.Id))
+	case strings.TrimSpace(tea.Name) == "":
+		return errors.New(fmt.Sprintf("Tea %d has no name", tea.Id))
+	case tea.Quantity < 0:
+		return errors.New(fmt.Sprintf("Tea %d has a negative quantity: %.1f", tea.Id, tea.Quantity))
+	case tea.Purchased.Price < 0:
+		return errors.New(fmt.Sprintf("Tea %d has a negative price: %.2f", tea.Id, tea.Purchased.Price))
+	}
+
+	for name, rating := range tea.Ratings {
+		if _, ok := productRatingName(name); !ok {
+			return errors.New(fmt.Sprintf("Unknown product rating: %s", name))
+		}
+		if rating < 0 || rating > MaxRating {
+			return errors.New(fmt.Sprintf("Product rating %s of %d is not between 0 and %d", name, rating, MaxRating))
+		}
+	}
+
+	for _, c := range tea.Blend {
+		if c.Ratio <= 0 {
+			return errors.New(fmt.Sprintf("Blend %d has a non-positive ratio of tea %d", tea.Id, c.Tea))
+		}
+	}
+
+	teas := make(map[int]Tea)
+	for id, t := range d.teas {
+		teas[id] = t
+	}
+	teas[tea.Id] = tea
+	return (&TeaDb{teas: teas}).validateBlend(tea, make(map[int]struct{}))
+}
+
+func (d *TeaDb) validateEntry(entry Entry) error {
+	if _, ok := d.teas[entry.Tea]; !ok {
+		return errors.New(fmt.Sprintf("Entry references unknown tea: %d", entry.Tea))
+	}
+
+	switch {
+	case entry.DateTime.IsZero():
+		return errors.New("Entry has no date")
+	case entry.Rating < 0 || entry.Rating > MaxRating:
+		return errors.New(fmt.Sprintf("Rating %d is not between 0 and %d", entry.Rating, MaxRating))
+	case entry.SteepTime < 0:
+		return errors.New(fmt.Sprintf("Steep time is negative: %s", entry.SteepTime))
+	case entry.SteepingTemperature < 0:
+		return errors.New(fmt.Sprintf("Steeping temperature is negative: %d", entry.SteepingTemperature))
+	case entry.LeafGrams < 0:
+		return errors.New(fmt.Sprintf("Leaf amount is negative: %.1f", entry.LeafGrams))
+	case entry.WaterMl < 0:
+		return errors.New(fmt.Sprintf("Water amount is negative: %d", entry.WaterMl))
+	}
+
+	if _, ok := VesselTypes[entry.SteepingVessel]; !ok {
+		return errors.New(fmt.Sprintf("Unknown vessel type: %d", entry.SteepingVessel))
+	}
+	if entry.Vessel != "" {
+		if _, ok := LookupVessel(entry.Vessel); !ok {
+			return errors.New(fmt.Sprintf("Unknown vessel: %s", entry.Vessel))
+		}
+	}
+	for _, f := range entry.Fixins {
+		if _, ok := TeaFixins[f]; !ok {
+			return errors.New(fmt.Sprintf("Unknown fixin: %d", f))
+		}
+	}
+
+	return nil
+}
+
+// NextTeaId returns the id following the greatest id of the teas
+func (d *TeaDb) NextTeaId() int {
+	var max int
+	for id := range d.teas {
+		if id > max {
+			max = id
+		}
+	}
+	return max + 1
+}
+
+// AddTea adds a new tea, which is given the next tea id if it has none, and returns the added tea
+func (d *TeaDb) AddTea(tea Tea) (Tea, error) {
+	if tea.Id == 0 {
+		tea.Id = d.NextTeaId()
+	}
+	if _, ok := d.teas[tea.Id]; ok {
+		return *new(Tea), errors.New(fmt.Sprintf("Tea %d already exists", tea.Id))
+	}
+	if err := d.validateTea(tea); err != nil {
+		return *new(Tea), err
+	}
+
+	tea.log = make(map[time.Time]Entry)
+	tea.logSortedKeys = make(TimeSlice, 0)
+	if err := d.store.PutTea(tea); err != nil {
+		return *new(Tea), err
+	}
+
+	d.teas[tea.Id] = tea
+	d.index = nil
+	return tea, nil
+}
+
+// UpdateTea replaces the details of the tea with the same id, keeping its entries
+func (d *TeaDb) UpdateTea(tea Tea) error {
+	existing, ok := d.teas[tea.Id]
+	if !ok {
+		return errors.New(fmt.Sprintf("Could not retrieve Tea by id: %d", tea.Id))
+	}
+	if err := d.validateTea(tea); err != nil {
+		return err
+	}
+
+	tea.log = existing.log
+	tea.logSortedKeys = existing.logSortedKeys
+	tea.average, tea.median, tea.mode = existing.average, existing.median, existing.mode
+	if err := d.store.PutTea(tea); err != nil {
+		return err
+	}
+
+	d.teas[tea.Id] = tea
+	d.index = nil
+	return nil
+}
+
+// DeleteTea removes a tea which has no entries and is not part of a blend
+func (d *TeaDb) DeleteTea(id int) error {
+	tea, ok := d.teas[id]
+	if !ok {
+		return errors.New(fmt.Sprintf("Could not retrieve Tea by id: %d", id))
+	}
+	if tea.LogLen() > 0 {
+		return errors.New(fmt.Sprintf("Tea %d still has %d entries", id, tea.LogLen()))
+	}
+	for _, other := range d.teas {
+		for _, c := range other.Blend {
+			if c.Tea == id {
+				return errors.New(fmt.Sprintf("Tea %d is blended into tea %d", id, other.Id))
+			}
+		}
+	}
+
+	if err := d.store.DeleteTea(id); err != nil {
+		return err
+	}
+
+	delete(d.teas, id)
+	d.index = nil
+	return nil
+}
+
+// Entry returns the entry logged at the given time
+func (d *TeaDb) Entry(at time.Time) (Entry, error) {
+	if entry, ok := d.log[at]; ok {
+		return entry, nil
+	}
+	for k, entry := range d.log {
+		if k.Equal(at) {
+			return entry, nil
+		}
+	}
+	return *new(Entry), errors.New(fmt.Sprintf("Could not retrieve Entry at: %s", at))
+}
+
+func (d *TeaDb) putEntry(entry Entry) {
+	d.log[entry.DateTime] = entry
+	d.logSortedKeys = append(d.logSortedKeys, entry.DateTime)
+	sort.Sort(d.logSortedKeys)
+
+	tea := d.teas[entry.Tea]
+	tea.Add(entry)
+	d.teas[entry.Tea] = tea
+	d.index = nil
+}
+
+func (d *TeaDb) removeEntry(entry Entry) {
+	for k := range d.log {
+		if k.Equal(entry.DateTime) {
+			delete(d.log, k)
+		}
+	}
+	keys := make(TimeSlice, 0, len(d.logSortedKeys))
+	for _, k := range d.logSortedKeys {
+		if !k.Equal(entry.DateTime) {
+			keys = append(keys, k)
+		}
+	}
+	d.logSortedKeys = keys
+
+	if tea, ok := d.teas[entry.Tea]; ok {
+		tea.remove(entry.DateTime)
+		d.teas[entry.Tea] = tea
+	}
+	d.index = nil
+}
+
+// AddEntry logs a new entry, which must be of a known tea and at a time that has no entry yet
+func (d *TeaDb) AddEntry(entry Entry) error {
+	if _, err := d.Entry(entry.DateTime); err == nil {
+		return errors.New(fmt.Sprintf("An entry already exists at: %s", entry.DateTime))
+	}
+	if err := d.validateEntry(entry); err != nil {
+		return err
+	}
+	entry.ParseTags()
+
+	if err := d.store.PutEntry(entry); err != nil {
+		return err
+	}
+
+	d.putEntry(entry)
+	return nil
+}
+
+// UpdateEntry replaces the entry logged at the given time, which can be moved to a time that has no entry yet
+func (d *TeaDb) UpdateEntry(at time.Time, entry Entry) error {
+	existing, err := d.Entry(at)
+	if err != nil {
+		return err
+	}
+	moved := !existing.DateTime.Equal(entry.DateTime)
+	if _, err := d.Entry(entry.DateTime); moved && err == nil {
+		return errors.New(fmt.Sprintf("An entry already exists at: %s", entry.DateTime))
+	}
+	if err := d.validateEntry(entry); err != nil {
+		return err
+	}
+	entry.ParseTags()
+
+	// The entry is written at its new time before it is deleted from the old one so that it is never lost
+	if err := d.store.PutEntry(entry); err != nil {
+		return err
+	}
+	if moved {
+		if err := d.store.DeleteEntry(existing.DateTime); err != nil {
+			d.putEntry(entry)
+			return err
+		}
+	}
+
+	d.removeEntry(existing)
+	d.putEntry(entry)
+	return nil
+}
+
+// DeleteEntry removes the entry logged at the given time
+func (d *TeaDb) DeleteEntry(at time.Time) error {
+	existing, err := d.Entry(at)
+	if err != nil {
+		return err
+	}
+
+	if err := d.store.DeleteEntry(existing.DateTime); err != nil {
+		return err
+	}
+
+	d.removeEntry(existing)
+	return nil
+}
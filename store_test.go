@@ -0,0 +1,295 @@
+package hgtealib
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+// recordingStore records the changes written to it, or fails them all when failing is set and only the deletions
+// of entries when failingDeletes is set
+type recordingStore struct {
+	teas           map[int]Tea
+	entries        map[time.Time]Entry
+	failing        bool
+	failingDeletes bool
+}
+
+func newRecordingStore() *recordingStore {
+	return &recordingStore{teas: make(map[int]Tea), entries: make(map[time.Time]Entry)}
+}
+
+func (s *recordingStore) PutTea(tea Tea) error {
+	if s.failing {
+		return errors.New("failing")
+	}
+	s.teas[tea.Id] = tea
+	return nil
+}
+
+func (s *recordingStore) DeleteTea(id int) error {
+	if s.failing {
+		return errors.New("failing")
+	}
+	delete(s.teas, id)
+	return nil
+}
+
+func (s *recordingStore) PutEntry(entry Entry) error {
+	if s.failing {
+		return errors.New("failing")
+	}
+	s.entries[entry.DateTime] = entry
+	return nil
+}
+
+func (s *recordingStore) DeleteEntry(at time.Time) error {
+	if s.failing || s.failingDeletes {
+		return errors.New("failing")
+	}
+	delete(s.entries, at)
+	return nil
+}
+
+func newWritableTestDb(t *testing.T) (*TeaDb, *recordingStore) {
+	db, err := newTeaDb([]*Tea{{Id: 1, Name: "Dong Ding", Type: "Oolong"}, {Id: 2, Name: "Bai Mu Dan", Type: "White"}}, []*Entry{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := newRecordingStore()
+	db.store = store
+	return db, store
+}
+
+func TestTeaDbReadOnly(t *testing.T) {
+	db, err := newTeaDb(testTeas, testEntries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if db.ReadOnly() {
+		t.Error("An in-memory database is read-only")
+	}
+
+	db.store = readOnlyStore{"tsv"}
+	if !db.ReadOnly() {
+		t.Error("Database is not read-only")
+	}
+
+	err = db.AddEntry(Entry{Tea: testTeas[0].Id, DateTime: time.Now(), Rating: 3})
+	if _, ok := err.(ReadOnlyError); !ok {
+		t.Fatalf("Expected a ReadOnlyError but received: %v", err)
+	}
+	if err.Error() != "The tsv database is read-only" {
+		t.Errorf("Unexpected error message: %s", err)
+	}
+	if log, _ := db.Log(NewFilter()); len(log) != len(testEntries) {
+		t.Error("Entry was added to a read-only database")
+	}
+
+	if err := db.UpdateTea(*testTeas[0]); err == nil {
+		t.Error("Updated a tea of a read-only database")
+	}
+}
+
+func TestTeaDbAddEntry(t *testing.T) {
+	db, store := newWritableTestDb(t)
+
+	now := time.Now()
+	if err := db.AddEntry(Entry{Tea: 1, DateTime: now, Rating: 4, Comments: "#roasty"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AddEntry(Entry{Tea: 1, DateTime: now.Add(time.Hour), Rating: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.entries) != 2 {
+		t.Errorf("Expected 2 entries in the store but found %d", len(store.entries))
+	}
+
+	tea, _ := db.Tea(1)
+	if tea.LogLen() != 2 || tea.Average() != 3 {
+		t.Errorf("Tea log was not updated: %d entries with an average of %d", tea.LogLen(), tea.Average())
+	}
+
+	entry, err := db.Entry(now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entry.Tags) != 1 || entry.Tags[0].Name != "roasted" {
+		t.Errorf("Entry was not tagged: %v", entry.Tags)
+	}
+
+	invalid := []Entry{
+		{Tea: 1, DateTime: now, Rating: 3},
+		{Tea: 99, DateTime: now.Add(2 * time.Hour), Rating: 3},
+		{Tea: 1, Rating: 3},
+		{Tea: 1, DateTime: now.Add(3 * time.Hour), Rating: MaxRating + 1},
+		{Tea: 1, DateTime: now.Add(4 * time.Hour), SteepTime: -time.Second},
+		{Tea: 1, DateTime: now.Add(5 * time.Hour), SteepingVessel: VesselType(-1)},
+		{Tea: 1, DateTime: now.Add(6 * time.Hour), Vessel: "Samovar"},
+		{Tea: 1, DateTime: now.Add(7 * time.Hour), Fixins: []TeaFixin{TeaFixin(-1)}},
+	}
+	for _, e := range invalid {
+		if err := db.AddEntry(e); err == nil {
+			t.Errorf("Added invalid entry: %+v", e)
+		}
+	}
+
+	store.failing = true
+	if err := db.AddEntry(Entry{Tea: 2, DateTime: now.Add(-time.Hour)}); err == nil {
+		t.Error("Did not receive the error of the store")
+	}
+	if tea, _ := db.Tea(2); tea.LogLen() != 0 {
+		t.Error("Entry was added although the store failed")
+	}
+}
+
+func TestTeaDbUpdateEntry(t *testing.T) {
+	db, store := newWritableTestDb(t)
+
+	now := time.Now()
+	if err := db.AddEntry(Entry{Tea: 1, DateTime: now, Rating: 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	later := now.Add(time.Hour)
+	if err := db.UpdateEntry(now, Entry{Tea: 2, DateTime: later, Rating: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := db.Entry(now); err == nil {
+		t.Error("Entry was not moved")
+	}
+	if _, ok := store.entries[now]; ok || len(store.entries) != 1 {
+		t.Errorf("Entry was not moved in the store: %v", store.entries)
+	}
+
+	dongDing, _ := db.Tea(1)
+	baiMuDan, _ := db.Tea(2)
+	if dongDing.LogLen() != 0 || baiMuDan.LogLen() != 1 || baiMuDan.Average() != 1 {
+		t.Errorf("Tea logs were not updated: %d and %d entries", dongDing.LogLen(), baiMuDan.LogLen())
+	}
+
+	if err := db.UpdateEntry(now, Entry{Tea: 1, DateTime: now}); err == nil {
+		t.Error("Updated an unavailable entry")
+	}
+
+	if err := db.DeleteEntry(later); err != nil {
+		t.Fatal(err)
+	}
+	if log, _ := db.Log(NewFilter()); len(log) != 0 || len(store.entries) != 0 {
+		t.Errorf("Entry was not deleted: %v", log)
+	}
+	if baiMuDan, _ := db.Tea(2); baiMuDan.LogLen() != 0 {
+		t.Error("Entry was not removed from the log of its tea")
+	}
+}
+
+func TestTeaDbUpdateEntryFailedDelete(t *testing.T) {
+	db, store := newWritableTestDb(t)
+
+	now := time.Date(2018, 1, 1, 8, 0, 0, 0, time.UTC)
+	if err := db.AddEntry(Entry{Tea: 1, DateTime: now, Rating: 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	// An entry which cannot be deleted from its old time is still kept at its new one
+	store.failingDeletes = true
+	later := now.Add(time.Hour)
+	if err := db.UpdateEntry(now, Entry{Tea: 1, DateTime: later, Rating: 2}); err == nil {
+		t.Error("Did not receive expected error when the old entry could not be deleted")
+	}
+	if _, ok := store.entries[later]; !ok {
+		t.Errorf("Moved entry was not written to the store: %v", store.entries)
+	}
+	if _, err := db.Entry(later); err != nil {
+		t.Error(err)
+	}
+	if _, err := db.Entry(now); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTeaDbDeleteEntryOtherZone(t *testing.T) {
+	db, _ := newWritableTestDb(t)
+
+	now := time.Date(2018, 1, 1, 8, 0, 0, 0, time.UTC)
+	if err := db.AddEntry(Entry{Tea: 1, DateTime: now, Rating: 4}); err != nil {
+		t.Fatal(err)
+	}
+
+	// The log of the tea may keep the same time in another zone
+	tea := db.teas[1]
+	tea.remove(now)
+	tea.Add(Entry{Tea: 1, DateTime: now.In(time.FixedZone("EST", -5*60*60)), Rating: 4})
+	db.teas[1] = tea
+
+	if err := db.DeleteEntry(now); err != nil {
+		t.Fatal(err)
+	}
+	if log, _ := db.Log(NewFilter()); len(log) != 0 {
+		t.Errorf("Entry was not deleted: %v", log)
+	}
+	if dongDing, _ := db.Tea(1); dongDing.LogLen() != 0 {
+		t.Error("Entry was not removed from the log of its tea")
+	}
+}
+
+func TestTeaDbTeaMutations(t *testing.T) {
+	db, store := newWritableTestDb(t)
+
+	tea, err := db.AddTea(Tea{Name: "Jasmine Pearls", Type: "Green"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tea.Id != 3 || store.teas[3].Name != "Jasmine Pearls" {
+		t.Errorf("Tea was not added with the next id: %+v", tea)
+	}
+
+	if _, err := db.AddTea(Tea{Id: 1, Name: "Duplicate"}); err == nil {
+		t.Error("Added a tea with an id that is taken")
+	}
+	if _, err := db.AddTea(Tea{Name: " "}); err == nil {
+		t.Error("Added a tea without a name")
+	}
+	if _, err := db.AddTea(Tea{Name: "Blend", Blend: TeaBlend{{Tea: 99, Ratio: 1}}}); err == nil {
+		t.Error("Added a blend of an unknown tea")
+	}
+
+	if err := db.AddEntry(Entry{Tea: 1, DateTime: time.Now(), Rating: 3}); err != nil {
+		t.Fatal(err)
+	}
+
+	updated, _ := db.Tea(1)
+	updated.Name = "Roasted Dong Ding"
+	if err := db.UpdateTea(updated); err != nil {
+		t.Fatal(err)
+	}
+	if tea, _ := db.Tea(1); tea.Name != "Roasted Dong Ding" || tea.LogLen() != 1 {
+		t.Errorf("Tea was not updated with its entries kept: %+v", tea)
+	}
+	if err := db.UpdateTea(Tea{Id: 99, Name: "Unknown"}); err == nil {
+		t.Error("Updated an unavailable tea")
+	}
+
+	blend, err := db.AddTea(Tea{Name: "House Blend", Blend: TeaBlend{{Tea: 2, Ratio: 1}, {Tea: 3, Ratio: 1}}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.DeleteTea(1); err == nil {
+		t.Error("Deleted a tea with entries")
+	}
+	if err := db.DeleteTea(3); err == nil {
+		t.Error("Deleted a tea which is part of a blend")
+	}
+	if err := db.DeleteTea(blend.Id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Tea(blend.Id); err == nil {
+		t.Error("Tea was not deleted")
+	}
+	if _, ok := store.teas[blend.Id]; ok {
+		t.Error("Tea was not deleted from the store")
+	}
+}
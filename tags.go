@@ -0,0 +1,205 @@
+package hgtealib
+
+import (
+	"errors"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// FlavorNote is a descriptor of a flavor lexicon, such as a flavor wheel, along with the words that are taken to
+// mean it. The name is also one of the words.
+type FlavorNote struct {
+	Name     string
+	Category string
+	Terms    []string
+}
+
+// FlavorNotes is the flavor lexicon matched against the comments of entries, keyed by name, which can be added to
+// from the configuration
+var FlavorNotes = map[string]FlavorNote{
+	"floral":      {"floral", "Floral", []string{"flowery", "jasmine", "rose", "orchid", "lilac", "osmanthus"}},
+	"stone fruit": {"stone fruit", "Fruity", []string{"peach", "apricot", "plum", "nectarine"}},
+	"citrus":      {"citrus", "Fruity", []string{"lemon", "orange", "bergamot", "grapefruit"}},
+	"berry":       {"berry", "Fruity", []string{"strawberry", "raspberry", "blueberry", "currant"}},
+	"tropical":    {"tropical", "Fruity", []string{"mango", "pineapple", "lychee", "passion fruit"}},
+	"dried fruit": {"dried fruit", "Fruity", []string{"raisin", "fig", "prune"}},
+	"grassy":      {"grassy", "Vegetal", []string{"grass", "hay", "straw"}},
+	"vegetal":     {"vegetal", "Vegetal", []string{"spinach", "green bean", "asparagus", "vegetable"}},
+	"marine":      {"marine", "Vegetal", []string{"seaweed", "umami", "brothy"}},
+	"honey":       {"honey", "Sweet", []string{"nectar"}},
+	"caramel":     {"caramel", "Sweet", []string{"toffee", "brown sugar", "molasses"}},
+	"malty":       {"malty", "Sweet", []string{"malt", "bready"}},
+	"chocolate":   {"chocolate", "Sweet", []string{"cocoa", "cacao"}},
+	"nutty":       {"nutty", "Nutty", []string{"nut", "chestnut", "almond", "walnut", "hazelnut"}},
+	"roasted":     {"roasted", "Roasted", []string{"roasty", "toasty", "toasted", "charcoal"}},
+	"smoky":       {"smoky", "Roasted", []string{"smoke", "campfire", "tobacco"}},
+	"earthy":      {"earthy", "Earthy", []string{"soil", "forest floor", "mushroom", "leather"}},
+	"woody":       {"woody", "Earthy", []string{"wood", "cedar", "oak", "camphor"}},
+	"mineral":     {"mineral", "Earthy", []string{"wet stone", "slate", "flint"}},
+	"spicy":       {"spicy", "Spicy", []string{"spice", "cinnamon", "pepper", "clove", "ginger"}},
+	"astringent":  {"astringent", "Mouthfeel", []string{"astringency", "drying", "tannic"}},
+	"brisk":       {"brisk", "Mouthfeel", []string{"bright", "lively"}},
+	"smooth":      {"smooth", "Mouthfeel", []string{"silky", "creamy", "buttery"}},
+	"bitter":      {"bitter", "Mouthfeel", []string{"bitterness"}},
+}
+
+func AddFlavorNote(note FlavorNote) error {
+	note.Name = strings.ToLower(strings.TrimSpace(note.Name))
+	if note.Name == "" {
+		return errors.New("Flavor note has no name")
+	}
+	FlavorNotes[note.Name] = note
+	return nil
+}
+
+// Tag is a descriptor found in the comments of an entry, either a note of the flavor lexicon or a hashtag. The
+// category is empty for hashtags which are not in the lexicon.
+type Tag struct {
+	Name     string
+	Category string
+}
+
+var re_hashtag = regexp.MustCompile("#([\\p{L}\\p{N}_-]+)")
+
+func stemmedPhrase(s string) []string {
+	words := splitWords(s)
+	stems := make([]string, len(words))
+	for i, w := range words {
+		stems[i] = stem(w.text)
+	}
+	return stems
+}
+
+func containsPhrase(words, phrase []string) bool {
+	if len(phrase) == 0 {
+		return false
+	}
+	for i := 0; i+len(phrase) <= len(words); i++ {
+		match := true
+		for j := range phrase {
+			if words[i+j] != phrase[j] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return true
+		}
+	}
+	return false
+}
+
+// flavorNoteOfHashtag returns the note of the lexicon which one of its words run together, i.e.: #stonefruit
+func flavorNoteOfHashtag(hashtag string) (FlavorNote, bool) {
+	joined := stem(strings.NewReplacer("-", "", "_", "").Replace(strings.ToLower(hashtag)))
+	for _, note := range FlavorNotes {
+		for _, term := range append([]string{note.Name}, note.Terms...) {
+			if stem(strings.Join(tokenize(term), "")) == joined {
+				return note, true
+			}
+		}
+	}
+	return FlavorNote{}, false
+}
+
+// ExtractTags returns the hashtags and the notes of the flavor lexicon in the comments, sorted by name. The words of
+// the lexicon match regardless of their form, i.e.: "smoked" matches the "smoky" note.
+func ExtractTags(comments string) []Tag {
+	found := make(map[string]Tag)
+
+	for _, m := range re_hashtag.FindAllStringSubmatch(comments, -1) {
+		if note, ok := flavorNoteOfHashtag(m[1]); ok {
+			found[note.Name] = Tag{note.Name, note.Category}
+		} else {
+			name := strings.ToLower(m[1])
+			found[name] = Tag{Name: name}
+		}
+	}
+
+	words := stemmedPhrase(comments)
+	for _, note := range FlavorNotes {
+		for _, term := range append([]string{note.Name}, note.Terms...) {
+			if containsPhrase(words, stemmedPhrase(term)) {
+				found[note.Name] = Tag{note.Name, note.Category}
+				break
+			}
+		}
+	}
+
+	tags := make([]Tag, 0)
+	for _, tag := range found {
+		tags = append(tags, tag)
+	}
+	sort.Slice(tags, func(i, j int) bool { return tags[i].Name < tags[j].Name })
+	return tags
+}
+
+func (e *Entry) ParseTags() {
+	e.Tags = ExtractTags(e.Comments)
+}
+
+// TagStats are the entries which were given a tag, along with the ratings of the entries
+type TagStats struct {
+	Tag
+	Entries int
+	Teas    int
+	Average float64
+	Ratings map[int]int
+}
+
+// Tags groups the entries of the filtered teas by the group of their tea, and then counts the entries given each
+// tag. The tags of each group are sorted from the most to the least common. A nil grouping puts every entry in one
+// group named "".
+func (d *TeaDb) Tags(filter *Filter, group TeaGrouping) (map[string][]TagStats, error) {
+	if group == nil {
+		group = func(Tea) []string { return []string{""} }
+	}
+
+	log, err := d.TeasLog(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := make(map[string]map[string]*TagStats)
+	teas := make(map[string]map[string]map[int]struct{})
+	for _, entry := range log {
+		for _, g := range group(d.teas[entry.Tea]) {
+			if stats[g] == nil {
+				stats[g] = make(map[string]*TagStats)
+				teas[g] = make(map[string]map[int]struct{})
+			}
+			for _, tag := range entry.Tags {
+				s, ok := stats[g][tag.Name]
+				if !ok {
+					s = &TagStats{Tag: tag, Ratings: make(map[int]int)}
+					stats[g][tag.Name] = s
+					teas[g][tag.Name] = make(map[int]struct{})
+				}
+				s.Entries++
+				s.Average += float64(entry.Rating)
+				s.Ratings[entry.Rating]++
+				teas[g][tag.Name][entry.Tea] = struct{}{}
+			}
+		}
+	}
+
+	groups := make(map[string][]TagStats)
+	for g, tags := range stats {
+		groups[g] = make([]TagStats, 0)
+		for name, s := range tags {
+			s.Average /= float64(s.Entries)
+			s.Teas = len(teas[g][name])
+			groups[g] = append(groups[g], *s)
+		}
+		sort.Slice(groups[g], func(i, j int) bool {
+			a, b := groups[g][i], groups[g][j]
+			if a.Entries != b.Entries {
+				return a.Entries > b.Entries
+			}
+			return a.Name < b.Name
+		})
+	}
+
+	return groups, nil
+}
@@ -0,0 +1,94 @@
+package hgtealib
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExtractTags(t *testing.T) {
+	tests := map[string][]Tag{
+		"#roasty #stonefruit, a bit astringent": {
+			{"astringent", "Mouthfeel"},
+			{"roasted", "Roasted"},
+			{"stone fruit", "Fruity"},
+		},
+		"Smoked, with notes of PEACHES": {
+			{"smoky", "Roasted"},
+			{"stone fruit", "Fruity"},
+		},
+		"#morning #Stone-Fruit": {
+			{"morning", ""},
+			{"stone fruit", "Fruity"},
+		},
+		"forest floor and mushrooms": {
+			{"earthy", "Earthy"},
+		},
+		"stone cold": {},
+		"":           {},
+	}
+
+	for comments, expected := range tests {
+		if tags := ExtractTags(comments); !reflect.DeepEqual(tags, expected) {
+			t.Errorf("Extracted tags %v from '%s' instead of expected: %v", tags, comments, expected)
+		}
+	}
+}
+
+func TestAddFlavorNote(t *testing.T) {
+	defer delete(FlavorNotes, "petrichor")
+
+	if err := AddFlavorNote(FlavorNote{Name: " Petrichor ", Category: "Earthy", Terms: []string{"rain"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []Tag{{"petrichor", "Earthy"}}
+	if tags := ExtractTags("like after the rain"); !reflect.DeepEqual(tags, expected) {
+		t.Errorf("Extracted tags %v instead of expected: %v", tags, expected)
+	}
+
+	if err := AddFlavorNote(FlavorNote{Name: " "}); err == nil {
+		t.Error("Did not receive expected error when adding a flavor note without a name")
+	}
+}
+
+func TestTeaDbTags(t *testing.T) {
+	now := time.Now()
+	entries := []*Entry{
+		{Tea: testTeas[0].Id, DateTime: now, Rating: 4, Comments: "#roasty and smooth"},
+		{Tea: testTeas[0].Id, DateTime: now.Add(-time.Hour), Rating: 2, Comments: "roasted"},
+		{Tea: testTeas[1].Id, DateTime: now.Add(-2 * time.Hour), Rating: 3, Comments: "toasty"},
+	}
+	for _, e := range entries {
+		e.ParseTags()
+	}
+
+	db, err := newTeaDb(testTeas, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tags, err := db.Tags(NewFilter(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags) != 1 || len(tags[""]) != 2 {
+		t.Fatalf("Unexpected tags: %v", tags)
+	}
+
+	roasted := tags[""][0]
+	if roasted.Name != "roasted" || roasted.Entries != 3 || roasted.Teas != 2 || roasted.Average != 3 {
+		t.Errorf("Unexpected stats of the most common tag: %+v", roasted)
+	}
+	if roasted.Ratings[4] != 1 || roasted.Ratings[3] != 1 || roasted.Ratings[2] != 1 {
+		t.Errorf("Unexpected ratings of the most common tag: %v", roasted.Ratings)
+	}
+
+	tags, err = db.Tags(NewFilter(), TeaGroupings["Tea"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tags[testTeas[0].String()]) != 2 || len(tags[testTeas[1].String()]) != 1 {
+		t.Errorf("Unexpected tags by tea: %v", tags)
+	}
+}
@@ -0,0 +1,138 @@
+package hgtealib
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+type teaTypeNode struct {
+	name   string
+	parent string
+}
+
+// TeaTaxonomy is a tree of tea types, in which each type can have a parent type and any number of aliases
+type TeaTaxonomy struct {
+	types   map[string]teaTypeNode
+	aliases map[string]string
+}
+
+func NewTeaTaxonomy() *TeaTaxonomy {
+	t := new(TeaTaxonomy)
+	t.types = make(map[string]teaTypeNode)
+	t.aliases = make(map[string]string)
+	return t
+}
+
+// Add adds a type to the taxonomy as a child of the given parent type, which can be empty
+func (t *TeaTaxonomy) Add(name, parent string, aliases ...string) error {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return errors.New("Tea type name is empty")
+	}
+
+	if parent != "" {
+		parent = t.Canonical(parent)
+		for _, ancestor := range t.Ancestors(parent) {
+			if strings.EqualFold(ancestor, name) {
+				return errors.New(fmt.Sprintf("Tea type %s cannot be a descendant of itself", name))
+			}
+		}
+	}
+
+	t.types[strings.ToLower(name)] = teaTypeNode{name: name, parent: parent}
+	for _, alias := range aliases {
+		t.aliases[strings.ToLower(strings.TrimSpace(alias))] = name
+	}
+
+	return nil
+}
+
+// Canonical resolves aliases to the name of the type. Unknown types are returned as is.
+func (t *TeaTaxonomy) Canonical(name string) string {
+	key := strings.ToLower(strings.TrimSpace(name))
+	if alias, ok := t.aliases[key]; ok {
+		return alias
+	}
+	if node, ok := t.types[key]; ok {
+		return node.name
+	}
+	return strings.TrimSpace(name)
+}
+
+// Ancestors returns the type followed by each of its parent types, up to the root of the taxonomy
+func (t *TeaTaxonomy) Ancestors(name string) []string {
+	ancestors := make([]string, 0)
+
+	current := t.Canonical(name)
+	for current != "" {
+		ancestors = append(ancestors, current)
+		node, ok := t.types[strings.ToLower(current)]
+		if !ok {
+			break
+		}
+		current = node.parent
+	}
+
+	return ancestors
+}
+
+// IsA returns true if the type is the given type or one of its subtypes
+func (t *TeaTaxonomy) IsA(name, ancestor string) bool {
+	ancestor = t.Canonical(ancestor)
+	for _, a := range t.Ancestors(name) {
+		if strings.EqualFold(a, ancestor) {
+			return true
+		}
+	}
+	return false
+}
+
+// Level returns the ancestor of the type at the given depth of the taxonomy, where 0 is the root. Types which are
+// not as deep as the level are returned as is.
+func (t *TeaTaxonomy) Level(name string, level int) string {
+	ancestors := t.Ancestors(name)
+	if len(ancestors) == 0 {
+		return ""
+	}
+	if level < 0 || level >= len(ancestors) {
+		return ancestors[0]
+	}
+	return ancestors[len(ancestors)-1-level]
+}
+
+func DefaultTeaTaxonomy() *TeaTaxonomy {
+	t := NewTeaTaxonomy()
+
+	for _, v := range []struct {
+		name    string
+		parent  string
+		aliases []string
+	}{
+		{"Black", "", []string{"Red", "Hong Cha"}},
+		{"Green", "", nil},
+		{"White", "", nil},
+		{"Yellow", "", nil},
+		{"Oolong", "", []string{"Wulong"}},
+		{"Roasted Oolong", "Oolong", nil},
+		{"Dan Cong", "Oolong", []string{"Dancong", "Phoenix"}},
+		{"Pu-erh", "", []string{"Puer", "Pu'er", "Pu-er", "Puerh"}},
+		{"Sheng Pu-erh", "Pu-erh", []string{"Sheng", "Raw Pu-erh"}},
+		{"Shou Pu-erh", "Pu-erh", []string{"Shou", "Ripe Pu-erh"}},
+		{"Herbal", "", []string{"Tisane"}},
+	} {
+		t.Add(v.name, v.parent, v.aliases...)
+	}
+
+	return t
+}
+
+// TeaTypes is the taxonomy used to relate the types of the teas
+var TeaTypes = DefaultTeaTaxonomy()
+
+// TeaTypeGrouping groups teas by their type at the given level of the taxonomy
+func TeaTypeGrouping(level int) TeaGrouping {
+	return func(t Tea) []string {
+		return []string{TeaTypes.Level(t.Type, level)}
+	}
+}
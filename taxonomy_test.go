@@ -0,0 +1,151 @@
+package hgtealib
+
+import "testing"
+
+func createTestTaxonomy(t *testing.T) *TeaTaxonomy {
+	taxonomy := NewTeaTaxonomy()
+
+	for _, v := range [][]string{
+		{"Oolong", ""},
+		{"Roasted Oolong", "Oolong"},
+		{"Dan Cong", "roasted oolong", "Dancong", "Phoenix"},
+		{"Black", ""},
+	} {
+		if err := taxonomy.Add(v[0], v[1], v[2:]...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return taxonomy
+}
+
+func TestTeaTaxonomyAdd(t *testing.T) {
+	taxonomy := createTestTaxonomy(t)
+
+	if err := taxonomy.Add("Oolong", "Dan Cong"); err == nil {
+		t.Error("Was able to make a tea type a descendant of itself")
+	}
+
+	if err := taxonomy.Add("", "Oolong"); err == nil {
+		t.Error("Was able to add a tea type without a name")
+	}
+}
+
+func TestTeaTaxonomyCanonical(t *testing.T) {
+	taxonomy := createTestTaxonomy(t)
+
+	tests := map[string]string{
+		"phoenix":        "Dan Cong",
+		"DAN CONG":       "Dan Cong",
+		" oolong ":       "Oolong",
+		"Black Flavored": "Black Flavored",
+	}
+
+	for name, expected := range tests {
+		if canonical := taxonomy.Canonical(name); canonical != expected {
+			t.Errorf("Expected '%s' to be resolved to '%s' but found '%s'", name, expected, canonical)
+		}
+	}
+}
+
+func TestTeaTaxonomyAncestors(t *testing.T) {
+	taxonomy := createTestTaxonomy(t)
+
+	ancestors := taxonomy.Ancestors("Dancong")
+	expected := []string{"Dan Cong", "Roasted Oolong", "Oolong"}
+	if len(ancestors) != len(expected) {
+		t.Fatalf("Expected ancestors %v but found %v", expected, ancestors)
+	}
+	for i, a := range expected {
+		if ancestors[i] != a {
+			t.Errorf("Expected ancestors %v but found %v", expected, ancestors)
+		}
+	}
+
+	if ancestors := taxonomy.Ancestors("Yellow"); len(ancestors) != 1 || ancestors[0] != "Yellow" {
+		t.Errorf("Unexpected ancestors of an unknown type: %v", ancestors)
+	}
+
+	if ancestors := taxonomy.Ancestors(""); len(ancestors) != 0 {
+		t.Errorf("Unexpected ancestors of an empty type: %v", ancestors)
+	}
+}
+
+func TestTeaTaxonomyIsA(t *testing.T) {
+	taxonomy := createTestTaxonomy(t)
+
+	if !taxonomy.IsA("Phoenix", "oolong") {
+		t.Error("Dan Cong is not an Oolong")
+	}
+
+	if !taxonomy.IsA("Roasted Oolong", "Roasted Oolong") {
+		t.Error("Roasted Oolong is not itself")
+	}
+
+	if taxonomy.IsA("Oolong", "Dan Cong") {
+		t.Error("Oolong is a Dan Cong")
+	}
+
+	if taxonomy.IsA("Black", "Oolong") {
+		t.Error("Black is an Oolong")
+	}
+}
+
+func TestTeaTaxonomyLevel(t *testing.T) {
+	taxonomy := createTestTaxonomy(t)
+
+	tests := []struct {
+		name     string
+		level    int
+		expected string
+	}{
+		{"Dan Cong", 0, "Oolong"},
+		{"Dan Cong", 1, "Roasted Oolong"},
+		{"Dan Cong", 2, "Dan Cong"},
+		{"Dan Cong", 5, "Dan Cong"},
+		{"Dan Cong", -1, "Dan Cong"},
+		{"Black", 1, "Black"},
+		{"", 0, ""},
+	}
+
+	for _, test := range tests {
+		if level := taxonomy.Level(test.name, test.level); level != test.expected {
+			t.Errorf("Expected level %d of '%s' to be '%s' but found '%s'", test.level, test.name, test.expected, level)
+		}
+	}
+}
+
+func TestTeaDbTeasTaxonomy(t *testing.T) {
+	defer func(taxonomy *TeaTaxonomy) {
+		TeaTypes = taxonomy
+	}(TeaTypes)
+	TeaTypes = createTestTaxonomy(t)
+
+	db, err := newTeaDb([]*Tea{
+		{Id: 1, Type: "Oolong"},
+		{Id: 2, Type: "Dan Cong"},
+		{Id: 3, Type: "phoenix"},
+		{Id: 4, Type: "Black"},
+	}, []*Entry{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	teas, err := db.Teas(NewFilter().Type("oolong"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(teas) != 3 {
+		t.Errorf("Expected 3 oolongs but found %d", len(teas))
+	}
+
+	stats, err := db.Stats(NewFilter(), TeaTypeGrouping(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(stats) != 2 || stats["Oolong"].Teas != 3 || stats["Black"].Teas != 1 {
+		t.Errorf("Unexpected stats rolled up to the top level: %+v", stats)
+	}
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"gitlab.com/hokiegeek/hgtealib"
+	"io"
+	"io/ioutil"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// addOptions are the fields of the entry given to the add command. Unset numbers are negative, and the names of
+// the flags which were given are kept in set.
+type addOptions struct {
+	tea         string
+	rating      int
+	steep       string
+	temperature int
+	vessel      string
+	fixins      string
+	comment     string
+	session     string
+	leaf        float64
+	water       int
+	interactive bool
+	set         map[string]bool
+}
+
+// parseAddArguments parses the arguments following the add command, i.e.: bai mu dan -rating 3 -steep 2m30s. The
+// name of the tea can be given before or after the flags.
+func parseAddArguments(args []string) (addOptions, error) {
+	var opts addOptions
+	fs := flag.NewFlagSet("add", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.IntVar(&opts.rating, "rating", -1, "The rating of the session, from 0 to 4")
+	fs.StringVar(&opts.steep, "steep", "", "The steep time (i.e.: 2m30s)")
+	fs.IntVar(&opts.temperature, "temp", -1, "The steeping temperature")
+	fs.StringVar(&opts.vessel, "vessel", "", "The name of the vessel or vessel type")
+	fs.StringVar(&opts.fixins, "fixins", "", "Comma-delimited list of the names of fixins")
+	fs.StringVar(&opts.comment, "comment", "", "Comments about the session")
+	fs.StringVar(&opts.session, "session", "", "The session to continue, or 'last' for the latest session of the tea")
+	fs.Float64Var(&opts.leaf, "leaf", -1, "The grams of leaf")
+	fs.IntVar(&opts.water, "water", -1, "The milliliters of water")
+	fs.BoolVar(&opts.interactive, "i", false, "Prompt for the fields which were not given")
+
+	name := make([]string, 0)
+	for len(args) > 0 {
+		if !strings.HasPrefix(args[0], "-") {
+			name = append(name, args[0])
+			args = args[1:]
+			continue
+		}
+		if err := fs.Parse(args); err != nil {
+			return opts, err
+		}
+		args = fs.Args()
+	}
+	opts.tea = strings.Join(name, " ")
+
+	opts.set = make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { opts.set[f.Name] = true })
+
+	return opts, nil
+}
+
+// given returns true if the flag was given, even if it was given an empty value
+func (o addOptions) given(flag string) bool {
+	return o.set[flag]
+}
+
+// missing returns true if any of the required fields were not given
+func (o addOptions) missing() bool {
+	return o.tea == "" || o.rating < 0
+}
+
+// prompter asks for the fields which were not given, offering a default which is used for an empty answer
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+func newPrompter(in io.Reader, out io.Writer) *prompter {
+	return &prompter{in: bufio.NewReader(in), out: out}
+}
+
+func (p *prompter) ask(label, def string) (string, error) {
+	if def != "" {
+		fmt.Fprintf(p.out, "%s [%s]: ", label, def)
+	} else {
+		fmt.Fprintf(p.out, "%s: ", label)
+	}
+
+	answer, err := p.in.ReadString('\n')
+	if err != nil && (err != io.EOF || answer == "") {
+		return "", err
+	}
+	if answer = strings.TrimSpace(answer); answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+func newSessionId() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func formatFixins(fixins []hgtealib.TeaFixin) string {
+	names := make([]string, len(fixins))
+	for i, f := range fixins {
+		names[i] = f.String()
+	}
+	return strings.Join(names, ",")
+}
+
+// newEntryFromAddOptions creates the entry described by the options at the given time. The fields which were not
+// given are prompted for when there is a prompter, offering those of the latest entry of the tea as defaults, and
+// are otherwise left empty.
+func newEntryFromAddOptions(db *hgtealib.TeaDb, opts addOptions, now time.Time, p *prompter) (hgtealib.Entry, error) {
+	var err error
+	ask := func(label, def string) (string, error) {
+		if p == nil {
+			return "", nil
+		}
+		return p.ask(label, def)
+	}
+
+	if opts.tea == "" {
+		if opts.tea, err = ask("Tea", ""); err != nil {
+			return hgtealib.Entry{}, err
+		}
+		if opts.tea == "" {
+			return hgtealib.Entry{}, errors.New("Expected the id or name of the tea to add an entry for")
+		}
+	}
+	tea, err := db.FindTea(opts.tea)
+	if err != nil {
+		return hgtealib.Entry{}, err
+	}
+
+	// The latest entry of the tea fills in the defaults of the prompts
+	var last *hgtealib.Entry
+	if log := tea.Log(); len(log) > 0 {
+		last = &log[len(log)-1]
+	}
+
+	entry := hgtealib.Entry{Tea: tea.Id, DateTime: now, Comments: opts.comment}
+
+	if opts.rating < 0 {
+		answer, err := ask(fmt.Sprintf("Rating (0-%d)", hgtealib.MaxRating), "")
+		if err != nil {
+			return hgtealib.Entry{}, err
+		}
+		if answer == "" {
+			return hgtealib.Entry{}, errors.New("Expected a rating, i.e.: -rating 3")
+		}
+		if opts.rating, err = strconv.Atoi(answer); err != nil {
+			return hgtealib.Entry{}, errors.New(fmt.Sprintf("Invalid rating: %s", answer))
+		}
+	}
+	entry.Rating = opts.rating
+
+	if !opts.given("steep") {
+		var def string
+		if last != nil && last.SteepTime > 0 {
+			def = last.SteepTime.String()
+		}
+		if opts.steep, err = ask("Steep time", def); err != nil {
+			return hgtealib.Entry{}, err
+		}
+	}
+	if opts.steep != "" {
+		if err := entry.ParseSteepTime(opts.steep); err != nil {
+			return hgtealib.Entry{}, err
+		}
+	}
+
+	var temperature string
+	if opts.given("temp") {
+		temperature = strconv.Itoa(opts.temperature)
+	} else {
+		var def string
+		if last != nil && last.SteepingTemperature > 0 {
+			def = strconv.Itoa(last.SteepingTemperature)
+		}
+		if temperature, err = ask("Temperature", def); err != nil {
+			return hgtealib.Entry{}, err
+		}
+	}
+	if temperature != "" {
+		if entry.SteepingTemperature, err = strconv.Atoi(temperature); err != nil || entry.SteepingTemperature < 0 {
+			return hgtealib.Entry{}, errors.New(fmt.Sprintf("Invalid temperature: %s", temperature))
+		}
+	}
+
+	if !opts.given("vessel") {
+		var def string
+		if last != nil {
+			def = last.VesselName()
+		}
+		if opts.vessel, err = ask("Vessel", def); err != nil {
+			return hgtealib.Entry{}, err
+		}
+	}
+	if opts.vessel != "" {
+		if err := entry.ParseVessel(opts.vessel); err != nil {
+			return hgtealib.Entry{}, err
+		}
+	}
+
+	// An empty list of fixins, i.e.: -fixins "", means that none were used
+	if !opts.given("fixins") {
+		var def string
+		if last != nil {
+			def = formatFixins(last.Fixins)
+		}
+		if opts.fixins, err = ask("Fixins", def); err != nil {
+			return hgtealib.Entry{}, err
+		}
+	}
+	for _, f := range strings.Split(opts.fixins, ",") {
+		if strings.TrimSpace(f) == "" {
+			continue
+		}
+		fixin, err := hgtealib.ParseTeaFixin(f)
+		if err != nil {
+			return hgtealib.Entry{}, err
+		}
+		entry.Fixins = append(entry.Fixins, fixin)
+	}
+
+	if !opts.given("comment") {
+		if entry.Comments, err = ask("Comments", ""); err != nil {
+			return hgtealib.Entry{}, err
+		}
+	}
+
+	if opts.given("leaf") {
+		entry.LeafGrams = opts.leaf
+	} else {
+		var def string
+		if last != nil && last.LeafGrams > 0 {
+			def = strconv.FormatFloat(last.LeafGrams, 'f', -1, 64)
+		}
+		answer, err := ask("Leaf grams", def)
+		if err != nil {
+			return hgtealib.Entry{}, err
+		}
+		if answer != "" {
+			if entry.LeafGrams, err = strconv.ParseFloat(answer, 64); err != nil {
+				return hgtealib.Entry{}, errors.New(fmt.Sprintf("Invalid leaf grams: %s", answer))
+			}
+		}
+	}
+	if entry.LeafGrams < 0 {
+		return hgtealib.Entry{}, errors.New(fmt.Sprintf("Invalid leaf grams: %g", entry.LeafGrams))
+	}
+
+	if opts.given("water") {
+		entry.WaterMl = opts.water
+	} else {
+		var def string
+		if last != nil && last.WaterMl > 0 {
+			def = strconv.Itoa(last.WaterMl)
+		}
+		answer, err := ask("Water ml", def)
+		if err != nil {
+			return hgtealib.Entry{}, err
+		}
+		if answer != "" {
+			if entry.WaterMl, err = strconv.Atoi(answer); err != nil {
+				return hgtealib.Entry{}, errors.New(fmt.Sprintf("Invalid water ml: %s", answer))
+			}
+		}
+	}
+	if entry.WaterMl < 0 {
+		return hgtealib.Entry{}, errors.New(fmt.Sprintf("Invalid water ml: %d", entry.WaterMl))
+	}
+
+	switch {
+	case opts.session == "last":
+		if last == nil || last.SessionInstance == "" {
+			return hgtealib.Entry{}, errors.New(fmt.Sprintf("%s has no session to continue", tea.String()))
+		}
+		entry.SessionInstance = last.SessionInstance
+	case opts.session != "":
+		log, _ := db.Log(hgtealib.NewFilter())
+		for _, e := range log {
+			if e.SessionInstance == opts.session && e.Tea != tea.Id {
+				other, _ := db.Tea(e.Tea)
+				return hgtealib.Entry{}, errors.New(fmt.Sprintf("Session %s is of %s, not %s", opts.session, other.String(), tea.String()))
+			}
+		}
+		entry.SessionInstance = opts.session
+	default:
+		entry.SessionInstance = newSessionId()
+	}
+
+	return entry, nil
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"bytes"
+	"gitlab.com/hokiegeek/hgtealib"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseAddArguments(t *testing.T) {
+	tests := [][]string{
+		{"bai", "mu", "dan", "-rating", "3", "-steep", "2m30s", "-fixins", "milk,honey"},
+		{"-rating", "3", "-steep", "2m30s", "bai", "mu", "dan", "-fixins", "milk,honey"},
+	}
+
+	for _, args := range tests {
+		opts, err := parseAddArguments(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts.tea != "bai mu dan" || opts.rating != 3 || opts.steep != "2m30s" || opts.fixins != "milk,honey" {
+			t.Errorf("Unexpected options from %v: %+v", args, opts)
+		}
+		if opts.temperature != -1 || opts.leaf != -1 || opts.water != -1 || opts.missing() {
+			t.Errorf("Unexpected defaults from %v: %+v", args, opts)
+		}
+		if !opts.given("fixins") || opts.given("temp") {
+			t.Errorf("Unexpected flags given from %v: %v", args, opts.set)
+		}
+	}
+
+	if _, err := parseAddArguments([]string{"tea", "-bogus"}); err == nil {
+		t.Error("Did not receive expected error on an unknown flag")
+	}
+}
+
+func newAddTestDb(t *testing.T) *hgtealib.TeaDb {
+	teas := []*hgtealib.Tea{{Id: 1, Name: "Bai Mu Dan"}, {Id: 2, Name: "Dong Ding"}}
+	entries := []*hgtealib.Entry{{
+		Tea:                 2,
+		DateTime:            time.Date(2018, 1, 1, 8, 0, 0, 0, time.UTC),
+		SteepTime:           45 * time.Second,
+		SteepingTemperature: 195,
+		SteepingVessel:      hgtealib.Gaiwan,
+		SessionInstance:     "DEADBEEF",
+		Fixins:              []hgtealib.TeaFixin{hgtealib.Honey},
+		LeafGrams:           5,
+	}}
+	db, err := hgtealib.NewTeaDb(nil, teas, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestNewEntryFromAddOptions(t *testing.T) {
+	db := newAddTestDb(t)
+	now := time.Now()
+
+	opts, _ := parseAddArguments(strings.Fields("bai mu dan -rating 3 -steep 2m30s -temp 180 -vessel gaiwan -fixins milk,honey -comment nice"))
+	entry, err := newEntryFromAddOptions(db, opts, now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Tea != 1 || !entry.DateTime.Equal(now) || entry.Rating != 3 || entry.SteepTime != 150*time.Second || entry.SteepingTemperature != 180 {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+	if entry.SteepingVessel != hgtealib.Gaiwan || len(entry.Fixins) != 2 || entry.Comments != "nice" || entry.SessionInstance == "" {
+		t.Errorf("Unexpected entry: %+v", entry)
+	}
+
+	// Without a prompter the fields which were not given are left empty instead of copied from the latest entry
+	opts, _ = parseAddArguments(strings.Fields("dong ding -rating 4 -session last"))
+	entry, err = newEntryFromAddOptions(db, opts, now, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry.SteepTime != 0 || entry.SteepingTemperature != 0 || entry.Vessel != "" || len(entry.Fixins) != 0 || entry.SessionInstance != "DEADBEEF" {
+		t.Errorf("Entry was filled in from the latest entry: %+v", entry)
+	}
+
+	opts, _ = parseAddArguments(strings.Fields("bai mu dan -rating 2"))
+	if entry, err = newEntryFromAddOptions(db, opts, now, nil); err != nil {
+		t.Fatal(err)
+	}
+	if entry.SteepingTemperature != 0 || entry.Vessel != "" || len(entry.Fixins) != 0 {
+		t.Errorf("Entry of a tea without entries was given defaults: %+v", entry)
+	}
+
+	for _, args := range []string{"bai mu dan", "bai mu dan -rating 3 -session last", "bai mu dan -rating 3 -session DEADBEEF", "bai mu dan -rating 3 -leaf -2", "bai mu dan -rating 3 -steep long", "sencha -rating 3"} {
+		opts, _ = parseAddArguments(strings.Fields(args))
+		if _, err := newEntryFromAddOptions(db, opts, now, nil); err == nil {
+			t.Errorf("Did not receive expected error from: %s", args)
+		}
+	}
+}
+
+func TestNewEntryFromAddOptionsPrompted(t *testing.T) {
+	db := newAddTestDb(t)
+
+	var out bytes.Buffer
+	p := newPrompter(strings.NewReader("dong\n2\n\n200\nbowl\n\nsmooth\n\n150\n"), &out)
+	entry, err := newEntryFromAddOptions(db, addOptions{rating: -1, temperature: -1, leaf: -1, water: -1}, time.Now(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if entry.Tea != 2 || entry.Rating != 2 || entry.SteepTime != 45*time.Second || entry.SteepingTemperature != 200 {
+		t.Errorf("Unexpected prompted entry: %+v", entry)
+	}
+	if entry.SteepingVessel != hgtealib.Bowl || len(entry.Fixins) != 1 || entry.Fixins[0] != hgtealib.Honey || entry.Comments != "smooth" {
+		t.Errorf("Unexpected prompted entry: %+v", entry)
+	}
+	if entry.LeafGrams != 5 || entry.WaterMl != 150 {
+		t.Errorf("Unexpected prompted leaf and water: %+v", entry)
+	}
+
+	expected := "Tea: Rating (0-4): Steep time [45s]: Temperature [195]: Vessel [Gaiwan]: Fixins [Honey]: Comments: Leaf grams [5]: Water ml: "
+	if out.String() != expected {
+		t.Errorf("Prompts '%s' do not match expected: %s", out.String(), expected)
+	}
+
+	// An empty flag is not prompted for, so -fixins "" means no fixins
+	out.Reset()
+	opts, _ := parseAddArguments([]string{"dong", "-rating", "3", "-steep", "1m", "-temp", "185", "-vessel", "gaiwan", "-fixins", "", "-comment", "", "-leaf", "0", "-water", "0"})
+	if entry, err = newEntryFromAddOptions(db, opts, time.Now(), newPrompter(strings.NewReader(""), &out)); err != nil {
+		t.Fatal(err)
+	}
+	if len(entry.Fixins) != 0 || entry.Comments != "" || out.String() != "" {
+		t.Errorf("Unexpected entry %+v from prompts: %s", entry, out.String())
+	}
+}
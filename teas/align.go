@@ -0,0 +1,231 @@
+package main
+
+import (
+	"fmt"
+	"github.com/mattn/go-runewidth"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const ellipsis = "…"
+
+// minColumnWidth is the narrowest that a column of text is truncated to when fitting a table to the terminal
+const minColumnWidth = 8
+
+// ratingColors are the ANSI colors of ratings, by the lowest rating which gets the color
+var ratingColors = []struct {
+	min   float64
+	color string
+}{
+	{4, "\x1b[1;32m"},
+	{3, "\x1b[32m"},
+	{2, "\x1b[33m"},
+	{0, "\x1b[31m"},
+}
+
+const colorReset = "\x1b[0m"
+
+const highlightColor = "\x1b[1;33m"
+
+// highlighted is a cell value with ranges of its text which are highlighted on a terminal
+type highlighted struct {
+	text   string
+	ranges [][2]int
+}
+
+func (h highlighted) String() string {
+	return h.text
+}
+
+// mark wraps each highlighted range of the cell, which may have been truncated, with the open and close strings
+func (h highlighted) mark(cell, open, close string) string {
+	var buf strings.Builder
+	prev := 0
+	limit := len(cell)
+	if strings.HasSuffix(cell, ellipsis) {
+		limit -= len(ellipsis)
+	}
+	for _, r := range h.ranges {
+		if r[0] >= limit || r[0] < prev {
+			break
+		}
+		end := r[1]
+		if end > limit {
+			end = limit
+		}
+		buf.WriteString(cell[prev:r[0]])
+		buf.WriteString(open)
+		buf.WriteString(cell[r[0]:end])
+		buf.WriteString(close)
+		prev = end
+	}
+	buf.WriteString(cell[prev:])
+	return buf.String()
+}
+
+// remap moves the highlighted ranges to the offsets of the text once it has been normalized
+func (h highlighted) remap(offsets []int) highlighted {
+	ranges := make([][2]int, 0, len(h.ranges))
+	for _, r := range h.ranges {
+		if r[0] < 0 || r[1] >= len(offsets) || r[0] > r[1] {
+			continue
+		}
+		if start, end := offsets[r[0]], offsets[r[1]]; start < end {
+			ranges = append(ranges, [2]int{start, end})
+		}
+	}
+	return highlighted{text: h.text, ranges: ranges}
+}
+
+// normalizeCell trims the cell and replaces the characters that would break its alignment with spaces. The offset
+// in the normalized text of each byte offset of the cell is also returned.
+func normalizeCell(s string) (string, []int) {
+	start := len(s) - len(strings.TrimLeftFunc(s, unicode.IsSpace))
+	end := len(strings.TrimRightFunc(s, unicode.IsSpace))
+	if end < start {
+		end = start
+	}
+
+	var buf strings.Builder
+	offsets := make([]int, len(s)+1)
+	for i := 0; i < len(s); {
+		offsets[i] = buf.Len()
+		switch {
+		case i < start || i >= end:
+			i++
+		case strings.HasPrefix(s[i:], "\r\n"):
+			buf.WriteByte(' ')
+			offsets[i+1] = buf.Len()
+			i += 2
+		case s[i] == '\t' || s[i] == '\n':
+			buf.WriteByte(' ')
+			i++
+		default:
+			buf.WriteByte(s[i])
+			i++
+		}
+	}
+	offsets[len(s)] = buf.Len()
+	return buf.String(), offsets
+}
+
+func colorRating(s string) string {
+	rating, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return s
+	}
+	for _, c := range ratingColors {
+		if rating >= c.min {
+			return c.color + s + colorReset
+		}
+	}
+	return s
+}
+
+// pad pads the string with spaces to the given display width, on the left if right aligned
+func pad(s string, width int, right bool) string {
+	padding := strings.Repeat(" ", width-runewidth.StringWidth(s))
+	if right {
+		return padding + s
+	}
+	return s + padding
+}
+
+// columnWidths measures the display width of each column of the table, shrinking the widest text columns when
+// the table is wider than the given width. A width of zero does not limit the table.
+func columnWidths(t table, cells [][]string, separator string, width int) []int {
+	widths := make([]int, len(t.fields))
+	for col := range t.fields {
+		widths[col] = runewidth.StringWidth(t.header(col))
+		for _, row := range cells {
+			if w := runewidth.StringWidth(row[col]); w > widths[col] {
+				widths[col] = w
+			}
+		}
+	}
+
+	if width <= 0 {
+		return widths
+	}
+
+	total := runewidth.StringWidth(separator) * (len(widths) - 1)
+	for _, w := range widths {
+		total += w
+	}
+
+	for total > width {
+		widest := -1
+		for col, w := range widths {
+			if !t.numeric(col) && w > minColumnWidth && (widest < 0 || w > widths[widest]) {
+				widest = col
+			}
+		}
+		if widest < 0 {
+			break
+		}
+
+		shrink := total - width
+		if shrink > widths[widest]-minColumnWidth {
+			shrink = widths[widest] - minColumnWidth
+		}
+		widths[widest] -= shrink
+		total -= shrink
+	}
+
+	return widths
+}
+
+// writeAligned writes the table with each column as wide as its content, truncating text with an ellipsis to fit
+// the terminal and coloring the ratings when the terminal supports it
+func writeAligned(w io.Writer, t table, opts viewOptions) {
+	separator := opts.delimeter
+	if separator == "\t" {
+		// Tab stops would undo the alignment
+		separator = "  "
+	}
+
+	cells := make([][]string, len(t.rows))
+	highlights := make(map[[2]int]highlighted)
+	for row := range t.rows {
+		cells[row] = make([]string, len(t.fields))
+		for col := range t.fields {
+			var offsets []int
+			cells[row][col], offsets = normalizeCell(t.cell(row, col, true))
+			if h, ok := t.rows[row][col].(highlighted); ok {
+				highlights[[2]int{row, col}] = h.remap(offsets)
+			}
+		}
+	}
+
+	widths := columnWidths(t, cells, separator, opts.width)
+	last := len(t.fields) - 1
+
+	header := make([]string, len(t.fields))
+	for col := range t.fields {
+		header[col] = runewidth.Truncate(t.header(col), widths[col], ellipsis)
+		if col != last || t.rightAligned(col) {
+			header[col] = pad(header[col], widths[col], t.rightAligned(col))
+		}
+	}
+	fmt.Fprintln(w, strings.Join(header, separator))
+
+	for r, row := range cells {
+		line := make([]string, len(t.fields))
+		for col, cell := range row {
+			cell = runewidth.Truncate(cell, widths[col], ellipsis)
+			if col != last || t.rightAligned(col) {
+				cell = pad(cell, widths[col], t.rightAligned(col))
+			}
+			if opts.color && t.ratings[t.fields[col]] {
+				cell = colorRating(cell)
+			}
+			if h, ok := highlights[[2]int{r, col}]; ok && opts.color && !t.rightAligned(col) {
+				cell = h.mark(cell, highlightColor, colorReset)
+			}
+			line[col] = cell
+		}
+		fmt.Fprintln(w, strings.Join(line, separator))
+	}
+}
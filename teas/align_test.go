@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func Example_writeAligned() {
+	t := newTable("", []string{"Id", "Name", "Origin"}, map[string]string{
+		"Id":     "%3d",
+		"Name":   "%-60s",
+		"Origin": "%30s",
+	})
+	for _, row := range [][]interface{}{{1, "東方美人 Oriental Beauty", "Taiwan"}, {12, "A very long name for a tea that does not fit", "Yunnan, China"}} {
+		t.addRow(func(field string) interface{} {
+			return map[string]interface{}{"Id": row[0], "Name": row[1], "Origin": row[2]}[field]
+		})
+	}
+
+	writeAligned(os.Stdout, t, viewOptions{delimeter: "\t", width: 44})
+
+	// Output:
+	// Id  Name                              Origin
+	//  1  東方美人 Oriental Beauty          Taiwan
+	// 12  A very long name for a t…  Yunnan, China
+}
+
+func TestColumnWidths(t *testing.T) {
+	tbl := newTable("", []string{"Id", "Name"}, map[string]string{"Id": "%d", "Name": "%s"})
+	cells := [][]string{{"1234567890", strings.Repeat("x", 40)}}
+
+	widths := columnWidths(tbl, cells, " ", 0)
+	if widths[0] != 10 || widths[1] != 40 {
+		t.Errorf("Unexpected unlimited widths: %v", widths)
+	}
+
+	widths = columnWidths(tbl, cells, " ", 30)
+	if widths[0] != 10 || widths[1] != 19 {
+		t.Errorf("Unexpected fitted widths: %v", widths)
+	}
+
+	widths = columnWidths(tbl, cells, " ", 5)
+	if widths[0] != 10 || widths[1] != minColumnWidth {
+		t.Errorf("Shrunk columns past the minimum: %v", widths)
+	}
+}
+
+func TestWriteAlignedColor(t *testing.T) {
+	tbl := newTable("", []string{"Name", "Avg"}, map[string]string{"Name": "%s", "Avg": "%d"})
+	tbl.rate("Avg")
+	tbl.addRow(func(field string) interface{} {
+		return map[string]interface{}{"Name": "Test", "Avg": 4}[field]
+	})
+
+	var buf bytes.Buffer
+	writeAligned(&buf, tbl, viewOptions{delimeter: " ", color: true})
+	if !strings.Contains(buf.String(), ratingColors[0].color+"  4"+colorReset) {
+		t.Errorf("Rating was not colored: %q", buf.String())
+	}
+
+	buf.Reset()
+	writeAligned(&buf, tbl, viewOptions{delimeter: " "})
+	if strings.Contains(buf.String(), "\x1b") {
+		t.Errorf("Found color when it was disabled: %q", buf.String())
+	}
+}
+
+func TestHighlightedMark(t *testing.T) {
+	h := highlighted{"notes of stone fruit", [][2]int{{9, 14}, {15, 20}}}
+
+	if s := h.mark(h.text, "[", "]"); s != "notes of [stone] [fruit]" {
+		t.Errorf("Unexpected marked text: %q", s)
+	}
+
+	if s := h.mark("notes of sto"+ellipsis, "[", "]"); s != "notes of [sto]"+ellipsis {
+		t.Errorf("Unexpected marked truncated text: %q", s)
+	}
+
+	// The ranges follow the text once it is trimmed and its line endings are replaced
+	h = highlighted{"  notes\r\nof stone\r\nfruit", [][2]int{{12, 17}, {19, 24}}}
+	tbl := newTable("", []string{"Snippet"}, map[string]string{"Snippet": "%s"})
+	tbl.addRow(func(string) interface{} { return h })
+
+	var buf bytes.Buffer
+	writeAligned(&buf, tbl, viewOptions{delimeter: " ", color: true})
+	if expected := "notes of " + highlightColor + "stone" + colorReset + " " + highlightColor + "fruit" + colorReset + "\n"; !strings.HasSuffix(buf.String(), expected) {
+		t.Errorf("Unexpected highlights %q instead of: %q", buf.String(), expected)
+	}
+}
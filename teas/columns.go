@@ -0,0 +1,191 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"gitlab.com/hokiegeek/hgtealib"
+	"strings"
+	"time"
+)
+
+// teaColumn describes a field of a tea which can be selected with -fields. The value is displayed with the printf
+// format, and a nil value is displayed as an empty cell.
+type teaColumn struct {
+	name   string
+	header string
+	format string
+	value  func(tea hgtealib.Tea) interface{}
+}
+
+func nonZero(v float64) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return v
+}
+
+func nonEmpty(s string) interface{} {
+	if s == "" {
+		return nil
+	}
+	return s
+}
+
+func formatBlend(tea hgtealib.Tea) string {
+	shares := tea.Blend.Shares()
+	parts := make([]string, len(tea.Blend))
+	for i, c := range tea.Blend {
+		parts[i] = fmt.Sprintf("%d (%.0f%%)", c.Tea, shares[c.Tea]*100)
+	}
+	return strings.Join(parts, ", ")
+}
+
+var teaColumns = []teaColumn{
+	{"Id", "Id", "%3d", func(t hgtealib.Tea) interface{} { return t.Id }},
+	{"Name", "Name", "%-60s", func(t hgtealib.Tea) interface{} { return t.Name }},
+	{"Type", "Type", "%-15s", func(t hgtealib.Tea) interface{} { return t.Type }},
+	{"Year", "Year", "%d", func(t hgtealib.Tea) interface{} {
+		if t.Picked.Year == 0 {
+			return nil
+		}
+		return t.Picked.Year
+	}},
+	{"Flush", "Flush", "%16s", func(t hgtealib.Tea) interface{} { return t.Picked.Flush }},
+	{"Origin", "Origin", "%30s", func(t hgtealib.Tea) interface{} { return t.Origin.String() }},
+	{"Country", "Country", "%-15s", func(t hgtealib.Tea) interface{} { return t.Origin.Country }},
+	{"Region", "Region", "%-20s", func(t hgtealib.Tea) interface{} { return t.Origin.Region }},
+	{"Size", "Size", "%12s", func(t hgtealib.Tea) interface{} { return t.Size }},
+	{"Quantity", "Grams", "%7.1f", func(t hgtealib.Tea) interface{} { return nonZero(t.Quantity) }},
+	{"Leaf Per Session", "Leaf/Session", "%5.1f", func(t hgtealib.Tea) interface{} { return t.SessionLeaf() }},
+	{"Grade", "Grade", "%-8s", func(t hgtealib.Tea) interface{} { return t.LeafGrade.Code() }},
+	{"Leaf Style", "Leaf Style", "%-10s", func(t hgtealib.Tea) interface{} { return nonEmpty(t.LeafGrade.Style.String()) }},
+	{"Stocked", "Stocked", "%-7t", func(t hgtealib.Tea) interface{} { return t.Storage.Stocked }},
+	{"Aging", "Aging", "%-5t", func(t hgtealib.Tea) interface{} { return t.Storage.Aging }},
+	{"Purchase Location", "Purchased From", "%-25s", func(t hgtealib.Tea) interface{} { return t.Purchased.Location }},
+	{"Purchase Date", "Purchased", "%-10s", func(t hgtealib.Tea) interface{} {
+		if date := t.Purchased.DateString(); date != "" {
+			return date
+		}
+		return nil
+	}},
+	{"Price", "Price", "%10.2f", func(t hgtealib.Tea) interface{} { return nonZero(t.Purchased.Price) }},
+	{"Currency", "Currency", "%-8s", func(t hgtealib.Tea) interface{} { return t.Purchased.Currency }},
+	{"Packaging", "Packaging", "%10s", func(t hgtealib.Tea) interface{} { return t.Purchased.Packaging.String() }},
+	{"Blend", "Blend", "%-25s", func(t hgtealib.Tea) interface{} { return formatBlend(t) }},
+	{"Comments", "Comments", "%s", func(t hgtealib.Tea) interface{} { return t.Comments }},
+	{"Entries", "Entries", "%7d", func(t hgtealib.Tea) interface{} { return t.LogLen() }},
+	{"Sessions", "Sessions", "%8d", func(t hgtealib.Tea) interface{} { return t.Sessions() }},
+	{"Avg", "Avg", "%6d", func(t hgtealib.Tea) interface{} { return t.Average() }},
+	{"Median", "Median", "%6d", func(t hgtealib.Tea) interface{} { return t.Median() }},
+	{"Mode", "Mode", "%6d", func(t hgtealib.Tea) interface{} { return t.Mode() }},
+	{"Cost Per Session", "Cost/Session", "%12.2f", func(t hgtealib.Tea) interface{} { return nonZero(t.CostPerSession()) }},
+}
+
+// lookupTeaColumn returns the column of the given name, including a column for each of the product ratings
+func lookupTeaColumn(name string) (teaColumn, bool) {
+	for _, c := range teaColumns {
+		if c.name == name {
+			return c, true
+		}
+	}
+	for _, rating := range hgtealib.TeaProductRatings {
+		if rating == name {
+			return teaColumn{rating, rating, fmt.Sprintf("%%%dd", len(rating)), func(t hgtealib.Tea) interface{} {
+				if r, ok := t.Ratings[rating]; ok {
+					return r
+				}
+				return nil
+			}}, true
+		}
+	}
+	return teaColumn{}, false
+}
+
+// entryRow is a journal entry along with its tea and the entries of its session
+type entryRow struct {
+	entry   hgtealib.Entry
+	tea     hgtealib.Tea
+	session []hgtealib.Entry
+}
+
+// entryColumn describes a field of a journal entry which can be selected with -fields
+type entryColumn struct {
+	name   string
+	header string
+	format string
+	value  func(row entryRow) interface{}
+}
+
+var entryColumns = []entryColumn{
+	{"Time", "Time", "%-21s", func(r entryRow) interface{} { return r.entry.DateTime.Format(time.RFC822Z) }},
+	{"Date", "Date", "%-10s", func(r entryRow) interface{} { return r.entry.DateTime.Format("2006-01-02") }},
+	{"Tea", "Tea", "%-60s", func(r entryRow) interface{} { return r.tea.String() }},
+	{"Steep Time", "Steep Time", "%10s", func(r entryRow) interface{} { return r.entry.SteepTime.String() }},
+	{"Rating", "Rating", "%d", func(r entryRow) interface{} { return r.entry.Rating }},
+	{"Fixins", "Fixins", "%-25s", func(r entryRow) interface{} {
+		names := make([]string, len(r.entry.Fixins))
+		for i, f := range r.entry.Fixins {
+			names[i] = f.String()
+		}
+		return strings.Join(names, ", ")
+	}},
+	{"Vessel", "Vessel", "%-20s", func(r entryRow) interface{} { return r.entry.VesselName() }},
+	{"Vessel Type", "Vessel Type", "%-15s", func(r entryRow) interface{} { return r.entry.SteepingVessel.String() }},
+	{"Temp", "Temp", "%d°", func(r entryRow) interface{} { return r.entry.SteepingTemperature }},
+	{"Session", "Session", "%-35s", func(r entryRow) interface{} { return r.entry.SessionInstance }},
+	{"Comments", "Comments", "%s", func(r entryRow) interface{} { return r.entry.Comments }},
+	{"Tags", "Tags", "%-25s", func(r entryRow) interface{} {
+		names := make([]string, len(r.entry.Tags))
+		for i, t := range r.entry.Tags {
+			names[i] = t.Name
+		}
+		return strings.Join(names, ", ")
+	}},
+	{"Leaf", "Leaf", "%5s", func(r entryRow) interface{} { return formatOptional(r.entry.LeafGrams, "%.1f") }},
+	{"Water", "Water", "%5s", func(r entryRow) interface{} { return formatOptional(float64(r.entry.WaterMl), "%.0f") }},
+	{"Ratio", "Ratio", "%5s", func(r entryRow) interface{} { return formatOptional(r.entry.Ratio(), "%.1f") }},
+	{"Session Steeps", "Steeps", "%6d", func(r entryRow) interface{} { return len(r.session) }},
+	{"Session Avg", "Session Avg", "%11.2f", func(r entryRow) interface{} {
+		var total int
+		for _, e := range r.session {
+			total += e.Rating
+		}
+		return float64(total) / float64(len(r.session))
+	}},
+	{"Session Time", "Session Time", "%12s", func(r entryRow) interface{} {
+		var total time.Duration
+		for _, e := range r.session {
+			total += e.SteepTime
+		}
+		return total.String()
+	}},
+}
+
+// lookupEntryColumn returns the column of the given name. Every tea column is also available for the tea of the
+// entry by prefixing its name with "Tea ", i.e.: "Tea Type"
+func lookupEntryColumn(name string) (entryColumn, bool) {
+	for _, c := range entryColumns {
+		if c.name == name {
+			return c, true
+		}
+	}
+	if strings.HasPrefix(name, "Tea ") {
+		if c, ok := lookupTeaColumn(strings.TrimPrefix(name, "Tea ")); ok {
+			return entryColumn{name, "Tea " + c.header, c.format, func(r entryRow) interface{} { return c.value(r.tea) }}, true
+		}
+	}
+	return entryColumn{}, false
+}
+
+func unknownFields(fields []string, known func(string) bool) error {
+	unknown := make([]string, 0)
+	for _, field := range fields {
+		if !known(strings.TrimPrefix(field, "-")) {
+			unknown = append(unknown, field)
+		}
+	}
+	if len(unknown) > 0 {
+		return errors.New(fmt.Sprintf("Unknown fields: %s", strings.Join(unknown, ", ")))
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"gitlab.com/hokiegeek/hgtealib"
+	"testing"
+	"time"
+)
+
+func TestLookupTeaColumn(t *testing.T) {
+	tea := hgtealib.Tea{Id: 42, Name: "Test Tea", Ratings: map[string]int{"Value": 3}}
+	tea.Storage.Stocked = true
+
+	for _, test := range []struct {
+		field string
+		value interface{}
+	}{
+		{"Id", 42},
+		{"Name", "Test Tea"},
+		{"Stocked", true},
+		{"Year", nil},
+		{"Price", nil},
+		{"Value", 3},
+		{"Leaf Aroma", nil},
+	} {
+		column, ok := lookupTeaColumn(test.field)
+		if !ok {
+			t.Errorf("Did not find column %s", test.field)
+			continue
+		}
+		if v := column.value(tea); v != test.value {
+			t.Errorf("Expected %v for column %s but found %v", test.value, test.field, v)
+		}
+	}
+
+	if _, ok := lookupTeaColumn("Bogus"); ok {
+		t.Error("Found an unknown column")
+	}
+}
+
+func TestLookupEntryColumn(t *testing.T) {
+	row := entryRow{
+		entry: hgtealib.Entry{Rating: 4, SteepTime: time.Minute},
+		tea:   hgtealib.Tea{Type: "Oolong", Origin: hgtealib.TeaOrigin{Country: "Taiwan"}},
+	}
+	row.session = []hgtealib.Entry{row.entry, {Rating: 2, SteepTime: 2 * time.Minute}}
+
+	for _, test := range []struct {
+		field string
+		value interface{}
+	}{
+		{"Rating", 4},
+		{"Tea Type", "Oolong"},
+		{"Tea Country", "Taiwan"},
+		{"Session Steeps", 2},
+		{"Session Avg", 3.0},
+		{"Session Time", "3m0s"},
+	} {
+		column, ok := lookupEntryColumn(test.field)
+		if !ok {
+			t.Errorf("Did not find column %s", test.field)
+			continue
+		}
+		if v := column.value(row); v != test.value {
+			t.Errorf("Expected %v for column %s but found %v", test.value, test.field, v)
+		}
+	}
+
+	if _, ok := lookupEntryColumn("Tea Bogus"); ok {
+		t.Error("Found an unknown joined tea column")
+	}
+}
+
+func TestUnknownFields(t *testing.T) {
+	known := func(field string) bool { return field == "Id" }
+
+	if err := unknownFields([]string{"Id", "-Id"}, known); err != nil {
+		t.Error(err)
+	}
+
+	err := unknownFields([]string{"Id", "Bogus", "Nope"}, known)
+	if err == nil || err.Error() != "Unknown fields: Bogus, Nope" {
+		t.Errorf("Unexpected error for unknown fields: %v", err)
+	}
+}
+
+func TestLessField(t *testing.T) {
+	if !lessField(nil, 1) || lessField(1, nil) || lessField(nil, nil) {
+		t.Error("Empty values are not sorted first")
+	}
+	if !lessField(1.5, 2.5) || !lessField(false, true) || !lessField("a", "B") {
+		t.Error("Values are not sorted in ascending order")
+	}
+}
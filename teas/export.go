@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"gitlab.com/hokiegeek/hgtealib"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// exportOptions are the options of the export command
+type exportOptions struct {
+	format string
+	dir    string
+}
+
+// parseExportArguments parses the arguments following the export command, i.e.: -format tsv -dir backup
+func parseExportArguments(args []string) (exportOptions, error) {
+	var opts exportOptions
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	fs.StringVar(&opts.format, "format", "tsv", "The format to export to, which can only be tsv")
+	fs.StringVar(&opts.dir, "dir", ".", "The directory to write the teas.tsv and journal.tsv files to")
+
+	if err := fs.Parse(args); err != nil {
+		return opts, err
+	}
+	if fs.NArg() > 0 {
+		return opts, errors.New(fmt.Sprintf("Unexpected export arguments: %v", fs.Args()))
+	}
+	if opts.format != "tsv" {
+		return opts, errors.New(fmt.Sprintf("Unsupported export format: %s", opts.format))
+	}
+
+	return opts, nil
+}
+
+// exportTsv writes the teas and journal of the database to the teas.tsv and journal.tsv files of the directory,
+// returning the paths of the files
+func exportTsv(db *hgtealib.TeaDb, dir string) ([]string, error) {
+	paths := []string{filepath.Join(dir, "teas.tsv"), filepath.Join(dir, "journal.tsv")}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	teas, err := os.Create(paths[0])
+	if err != nil {
+		return nil, err
+	}
+	defer teas.Close()
+
+	journal, err := os.Create(paths[1])
+	if err != nil {
+		return nil, err
+	}
+	defer journal.Close()
+
+	if err := db.WriteTsv(teas, journal); err != nil {
+		return nil, err
+	}
+	if err := teas.Close(); err != nil {
+		return nil, err
+	}
+	return paths, journal.Close()
+}
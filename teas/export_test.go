@@ -0,0 +1,58 @@
+package main
+
+import (
+	"gitlab.com/hokiegeek/hgtealib"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseExportArguments(t *testing.T) {
+	opts, err := parseExportArguments([]string{"-format", "tsv", "-dir", "backup"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.format != "tsv" || opts.dir != "backup" {
+		t.Errorf("Unexpected options: %+v", opts)
+	}
+
+	if opts, err = parseExportArguments([]string{}); err != nil || opts.dir != "." {
+		t.Errorf("Unexpected default options: %+v %v", opts, err)
+	}
+
+	for _, args := range [][]string{{"-format", "json"}, {"backup"}, {"-bogus"}} {
+		if _, err := parseExportArguments(args); err == nil {
+			t.Errorf("Did not receive expected error from: %v", args)
+		}
+	}
+}
+
+func TestExportTsv(t *testing.T) {
+	dir, err := ioutil.TempDir("", "teas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	teas := []*hgtealib.Tea{{Id: 1, Name: "Bai Mu Dan"}}
+	entries := []*hgtealib.Entry{{Tea: 1, DateTime: time.Date(2018, 1, 1, 8, 0, 0, 0, time.UTC), Rating: 3, SteepingTemperature: 180}}
+	db, err := hgtealib.NewTeaDb(nil, teas, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	paths, err := exportTsv(db, filepath.Join(dir, "backup"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded, err := hgtealib.NewFromTsv("file://"+paths[0], "file://"+paths[1], "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log, _ := reloaded.Log(hgtealib.NewFilter()); len(log) != 1 || log[0].Rating != 3 || log[0].SteepingTemperature != 180 {
+		t.Errorf("Unexpected journal after export: %+v", log)
+	}
+}
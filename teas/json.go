@@ -0,0 +1,469 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gitlab.com/hokiegeek/hgtealib"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+)
+
+type teaOriginJson struct {
+	Country string `json:"country"`
+	Region  string `json:"region"`
+}
+
+type teaPurchaseJson struct {
+	Location  string     `json:"location"`
+	Date      *time.Time `json:"date"`
+	DateText  string     `json:"dateText,omitempty"`
+	Price     float64    `json:"price"`
+	Currency  string     `json:"currency"`
+	Packaging string     `json:"packaging"`
+}
+
+type teaBlendJson struct {
+	Tea   int     `json:"tea"`
+	Ratio float64 `json:"ratio"`
+}
+
+type teaStatsJson struct {
+	Entries        int     `json:"entries"`
+	Sessions       int     `json:"sessions"`
+	Average        int     `json:"average"`
+	Median         int     `json:"median"`
+	Mode           int     `json:"mode"`
+	CostPerSession float64 `json:"costPerSession"`
+}
+
+type teaJson struct {
+	Id        int             `json:"id"`
+	Name      string          `json:"name"`
+	Type      string          `json:"type"`
+	Year      int             `json:"year"`
+	Flush     string          `json:"flush"`
+	Origin    teaOriginJson   `json:"origin"`
+	Size      string          `json:"size"`
+	Quantity  float64         `json:"quantity"`
+	Grade     string          `json:"grade"`
+	Stocked   bool            `json:"stocked"`
+	Aging     bool            `json:"aging"`
+	Purchased teaPurchaseJson `json:"purchased"`
+	Ratings   map[string]int  `json:"ratings"`
+	Blend     []teaBlendJson  `json:"blend"`
+	Comments  string          `json:"comments,omitempty"`
+	Stats     teaStatsJson    `json:"stats"`
+}
+
+func newTeaJson(tea hgtealib.Tea) teaJson {
+	j := teaJson{
+		Id:       tea.Id,
+		Name:     tea.Name,
+		Type:     tea.Type,
+		Year:     tea.Picked.Year,
+		Flush:    tea.Picked.Flush.String(),
+		Origin:   teaOriginJson{Country: tea.Origin.Country, Region: tea.Origin.Region},
+		Size:     tea.Size,
+		Quantity: tea.Quantity,
+		Grade:    tea.LeafGrade.Code(),
+		Stocked:  tea.Storage.Stocked,
+		Aging:    tea.Storage.Aging,
+		Purchased: teaPurchaseJson{
+			Location:  tea.Purchased.Location,
+			DateText:  tea.Purchased.DateText,
+			Price:     tea.Purchased.Price,
+			Currency:  tea.Purchased.Currency,
+			Packaging: tea.Purchased.Packaging.String(),
+		},
+		Ratings:  tea.Ratings,
+		Blend:    make([]teaBlendJson, 0),
+		Comments: tea.Comments,
+		Stats: teaStatsJson{
+			Entries:        tea.LogLen(),
+			Sessions:       tea.Sessions(),
+			Average:        tea.Average(),
+			Median:         tea.Median(),
+			Mode:           tea.Mode(),
+			CostPerSession: tea.CostPerSession(),
+		},
+	}
+	if !tea.Purchased.Date.IsZero() {
+		date := tea.Purchased.Date
+		j.Purchased.Date = &date
+	}
+	if j.Ratings == nil {
+		j.Ratings = make(map[string]int)
+	}
+	for _, c := range tea.Blend {
+		j.Blend = append(j.Blend, teaBlendJson{Tea: c.Tea, Ratio: c.Ratio})
+	}
+	return j
+}
+
+type entryJson struct {
+	Tea          int       `json:"tea"`
+	TeaName      string    `json:"teaName"`
+	Time         time.Time `json:"time"`
+	Rating       int       `json:"rating"`
+	Comments     string    `json:"comments"`
+	SteepSeconds float64   `json:"steepSeconds"`
+	Vessel       string    `json:"vessel"`
+	VesselType   string    `json:"vesselType"`
+	Temperature  int       `json:"temperature"`
+	Session      string    `json:"session"`
+	Fixins       []string  `json:"fixins"`
+	LeafGrams    float64   `json:"leafGrams"`
+	WaterMl      int       `json:"waterMl"`
+	Ratio        float64   `json:"ratio"`
+	Tags         []string  `json:"tags"`
+}
+
+func newEntryJson(db *hgtealib.TeaDb, entry hgtealib.Entry) entryJson {
+	j := entryJson{
+		Tea:          entry.Tea,
+		Time:         entry.DateTime,
+		Rating:       entry.Rating,
+		Comments:     entry.Comments,
+		SteepSeconds: entry.SteepTime.Seconds(),
+		Vessel:       entry.VesselName(),
+		VesselType:   entry.SteepingVessel.String(),
+		Temperature:  entry.SteepingTemperature,
+		Session:      entry.SessionInstance,
+		Fixins:       make([]string, 0),
+		LeafGrams:    entry.LeafGrams,
+		WaterMl:      entry.WaterMl,
+		Ratio:        entry.Ratio(),
+	}
+	if tea, err := db.Tea(entry.Tea); err == nil {
+		j.TeaName = tea.String()
+	}
+	for _, f := range entry.Fixins {
+		j.Fixins = append(j.Fixins, f.String())
+	}
+	j.Tags = make([]string, 0)
+	for _, t := range entry.Tags {
+		j.Tags = append(j.Tags, t.Name)
+	}
+	return j
+}
+
+func newEntriesJson(db *hgtealib.TeaDb, log []hgtealib.Entry) []entryJson {
+	entries := make([]entryJson, 0)
+	for _, entry := range log {
+		entries = append(entries, newEntryJson(db, entry))
+	}
+	return entries
+}
+
+func newTeasJson(teas []hgtealib.Tea) []teaJson {
+	records := make([]teaJson, 0)
+	for _, tea := range teas {
+		records = append(records, newTeaJson(tea))
+	}
+	return records
+}
+
+type statsJson struct {
+	Group   string             `json:"group"`
+	Teas    int                `json:"teas"`
+	Entries int                `json:"entries"`
+	Average float64            `json:"average"`
+	Median  int                `json:"median"`
+	Mode    int                `json:"mode"`
+	Ratio   float64            `json:"ratio"`
+	Ratings map[string]float64 `json:"ratings"`
+}
+
+func newStatsJson(stats map[string]hgtealib.Stats) []statsJson {
+	records := make([]statsJson, 0)
+	for _, g := range sortedKeys(stats) {
+		s := stats[g]
+		records = append(records, statsJson{
+			Group:   g,
+			Teas:    s.Teas,
+			Entries: s.Entries,
+			Average: s.Average,
+			Median:  s.Median,
+			Mode:    s.Mode,
+			Ratio:   s.Ratio,
+			Ratings: s.Ratings,
+		})
+	}
+	return records
+}
+
+type spendJson struct {
+	By     string             `json:"by"`
+	Group  string             `json:"group"`
+	Teas   int                `json:"teas"`
+	Totals map[string]float64 `json:"totals"`
+}
+
+func newSpendJson(by string, spend map[string]hgtealib.Spend) []spendJson {
+	records := make([]spendJson, 0)
+	for _, g := range sortedKeys(spend) {
+		records = append(records, spendJson{By: by, Group: g, Teas: spend[g].Teas, Totals: spend[g].Totals})
+	}
+	return records
+}
+
+type inventoryJson struct {
+	Tea       int      `json:"tea"`
+	Name      string   `json:"name"`
+	Quantity  float64  `json:"quantity"`
+	Used      float64  `json:"used"`
+	Remaining float64  `json:"remaining"`
+	DailyRate float64  `json:"dailyRate"`
+	DaysLeft  *float64 `json:"daysLeft"`
+	Finished  bool     `json:"finished"`
+}
+
+func newInventoryJson(teas []hgtealib.Tea, inventory map[int]hgtealib.TeaInventory) []inventoryJson {
+	records := make([]inventoryJson, 0)
+	for _, tea := range teas {
+		i, ok := inventory[tea.Id]
+		if !ok || i.Quantity == 0 {
+			continue
+		}
+		j := inventoryJson{
+			Tea:       tea.Id,
+			Name:      tea.String(),
+			Quantity:  i.Quantity,
+			Used:      i.Used,
+			Remaining: i.Remaining,
+			DailyRate: i.DailyRate,
+			Finished:  i.Finished,
+		}
+		if days := i.DaysLeft(); days >= 0 {
+			j.DaysLeft = &days
+		}
+		records = append(records, j)
+	}
+	return records
+}
+
+type ageRatingJson struct {
+	Age     int     `json:"age"`
+	Entries int     `json:"entries"`
+	Average float64 `json:"average"`
+}
+
+type agingJson struct {
+	Tea           int             `json:"tea"`
+	Name          string          `json:"name"`
+	Packaging     string          `json:"packaging"`
+	Age           float64         `json:"age"`
+	Target        float64         `json:"target"`
+	ReachedTarget bool            `json:"reachedTarget"`
+	RatingsByAge  []ageRatingJson `json:"ratingsByAge"`
+}
+
+func newAgingJson(teas []hgtealib.Tea, now time.Time) []agingJson {
+	records := make([]agingJson, 0)
+	for _, tea := range teas {
+		age, ok := tea.AgeAt(now)
+		if !ok {
+			continue
+		}
+		j := agingJson{
+			Tea:           tea.Id,
+			Name:          tea.String(),
+			Packaging:     tea.Purchased.Packaging.String(),
+			Age:           age,
+			Target:        tea.AgingTarget(),
+			ReachedTarget: tea.ReachedAgingTarget(now),
+			RatingsByAge:  make([]ageRatingJson, 0),
+		}
+		for _, r := range tea.RatingsByAge() {
+			j.RatingsByAge = append(j.RatingsByAge, ageRatingJson{Age: r.Age, Entries: r.Entries, Average: r.Average})
+		}
+		records = append(records, j)
+	}
+	return records
+}
+
+type vesselJson struct {
+	Name      string         `json:"name"`
+	Type      string         `json:"type"`
+	Material  string         `json:"material"`
+	Volume    int            `json:"volume"`
+	Dedicated []string       `json:"dedicated"`
+	Entries   int            `json:"entries"`
+	Average   float64        `json:"average"`
+	Types     map[string]int `json:"types"`
+	Misuses   []entryJson    `json:"misuses"`
+}
+
+func newVesselsJson(db *hgtealib.TeaDb, use map[string]hgtealib.VesselUse) []vesselJson {
+	records := make([]vesselJson, 0)
+	for _, name := range sortedKeys(use) {
+		u := use[name]
+		j := vesselJson{
+			Name:      u.Vessel.Name,
+			Type:      u.Vessel.Type.String(),
+			Material:  u.Vessel.Material,
+			Volume:    u.Vessel.Volume,
+			Dedicated: u.Vessel.Dedicated,
+			Entries:   u.Entries,
+			Average:   u.Average,
+			Types:     u.Types,
+			Misuses:   newEntriesJson(db, u.Misuses),
+		}
+		if j.Dedicated == nil {
+			j.Dedicated = make([]string, 0)
+		}
+		records = append(records, j)
+	}
+	return records
+}
+
+type recommendationJson struct {
+	Entries      int     `json:"entries"`
+	Rating       int     `json:"rating"`
+	SteepSeconds float64 `json:"steepSeconds"`
+	Temperature  int     `json:"temperature"`
+	Ratio        float64 `json:"ratio"`
+	Vessel       string  `json:"vessel"`
+}
+
+type teaDetailJson struct {
+	teaJson
+	Age            *float64            `json:"age"`
+	AgingTarget    float64             `json:"agingTarget"`
+	RatingCounts   map[int]int         `json:"ratingCounts"`
+	Consumed       float64             `json:"consumed"`
+	Recommendation *recommendationJson `json:"recommendation"`
+	Entries        []entryJson         `json:"entries"`
+}
+
+func newTeaDetailJson(db *hgtealib.TeaDb, tea hgtealib.Tea, last int) teaDetailJson {
+	j := teaDetailJson{
+		teaJson:      newTeaJson(tea),
+		AgingTarget:  tea.AgingTarget(),
+		RatingCounts: tea.RatingCounts(),
+		Consumed:     db.Consumption()[tea.Id],
+		Entries:      newEntriesJson(db, lastEntries(tea, last)),
+	}
+	if age, ok := tea.AgeAt(time.Now()); ok {
+		j.Age = &age
+	}
+	if r, ok := tea.Recommendation(); ok {
+		j.Recommendation = &recommendationJson{
+			Entries:      r.Entries,
+			Rating:       r.Rating,
+			SteepSeconds: r.SteepTime.Seconds(),
+			Temperature:  r.Temperature,
+			Ratio:        r.Ratio,
+			Vessel:       r.Vessel,
+		}
+	}
+	return j
+}
+
+type tagJson struct {
+	Group    string      `json:"group"`
+	Tag      string      `json:"tag"`
+	Category string      `json:"category"`
+	Entries  int         `json:"entries"`
+	Teas     int         `json:"teas"`
+	Average  float64     `json:"average"`
+	Ratings  map[int]int `json:"ratings"`
+}
+
+func newTagsJson(tags map[string][]hgtealib.TagStats) []tagJson {
+	records := make([]tagJson, 0)
+	for _, g := range sortedKeys(tags) {
+		for _, s := range tags[g] {
+			records = append(records, tagJson{
+				Group:    g,
+				Tag:      s.Name,
+				Category: s.Category,
+				Entries:  s.Entries,
+				Teas:     s.Teas,
+				Average:  s.Average,
+				Ratings:  s.Ratings,
+			})
+		}
+	}
+	return records
+}
+
+type searchHitJson struct {
+	Tea        int        `json:"tea"`
+	TeaName    string     `json:"teaName"`
+	Entry      *entryJson `json:"entry"`
+	Field      string     `json:"field"`
+	Score      float64    `json:"score"`
+	Snippet    string     `json:"snippet"`
+	Highlights [][2]int   `json:"highlights"`
+}
+
+func newSearchJson(db *hgtealib.TeaDb, hits []hgtealib.SearchHit) []searchHitJson {
+	records := make([]searchHitJson, 0)
+	for _, hit := range hits {
+		j := searchHitJson{
+			Tea:        hit.Tea,
+			Field:      hit.Field,
+			Score:      hit.Score,
+			Snippet:    hit.Snippet,
+			Highlights: hit.Highlights,
+		}
+		if tea, err := db.Tea(hit.Tea); err == nil {
+			j.TeaName = tea.String()
+		}
+		if hit.Entry != nil {
+			entry := newEntryJson(db, *hit.Entry)
+			j.Entry = &entry
+		}
+		records = append(records, j)
+	}
+	return records
+}
+
+func sortedKeys(m interface{}) []string {
+	keys := make([]string, 0)
+	for _, k := range reflect.ValueOf(m).MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// writeJson writes the value as a single JSON document or, for the ndjson format, each element of a
+// slice on its own line
+func writeJson(w io.Writer, v interface{}, format string) error {
+	switch {
+	case format == "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case format == "ndjson":
+		encoder := json.NewEncoder(w)
+		if value := reflect.ValueOf(v); value.Kind() == reflect.Slice {
+			for i := 0; i < value.Len(); i++ {
+				if err := encoder.Encode(value.Index(i).Interface()); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+		return encoder.Encode(v)
+	default:
+		return errors.New(fmt.Sprintf("Unrecognized output format: %s", format))
+	}
+}
+
+func printJson(v interface{}, opts viewOptions) {
+	if err := writeJson(os.Stdout, v, opts.format); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func isJsonFormat(format string) bool {
+	return format == "json" || format == "ndjson"
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"gitlab.com/hokiegeek/hgtealib"
+	"os"
+	"testing"
+	"time"
+)
+
+func Example_writeJson() {
+	records := []statsJson{
+		{Group: "Black", Teas: 2, Entries: 3, Average: 2.5, Median: 3, Mode: 3},
+		{Group: "Green", Teas: 1, Entries: 1, Average: 4, Median: 4, Mode: 4},
+	}
+
+	writeJson(os.Stdout, records, "ndjson")
+
+	// Output:
+	// {"group":"Black","teas":2,"entries":3,"average":2.5,"median":3,"mode":3,"ratio":0,"ratings":null}
+	// {"group":"Green","teas":1,"entries":1,"average":4,"median":4,"mode":4,"ratio":0,"ratings":null}
+}
+
+func TestWriteJsonUnknownFormat(t *testing.T) {
+	if err := writeJson(os.Stdout, nil, "xml"); err == nil {
+		t.Error("Successfully wrote an unrecognized format")
+	}
+}
+
+func Example_newTeaJson() {
+	tea := hgtealib.Tea{
+		Id:        1,
+		Name:      "Dong Ding",
+		Type:      "Oolong",
+		Picked:    hgtealib.TeaPickPeriod{Year: 2017, Flush: hgtealib.TeaFlush{Flush: hgtealib.Second}},
+		Origin:    hgtealib.TeaOrigin{Country: "Taiwan", Region: "Nantou"},
+		Storage:   hgtealib.TeaStorageState{Stocked: true},
+		Purchased: hgtealib.TeaPurchaseInfo{Location: "Online", Date: time.Date(2018, 2, 3, 0, 0, 0, 0, time.UTC), Price: 12.5, Currency: "USD"},
+		LeafGrade: hgtealib.ParseLeafGrade("FTGFOP1"),
+		Ratings:   map[string]int{"Value": 3},
+		Blend:     hgtealib.TeaBlend{{Tea: 2, Ratio: 1}},
+		Comments:  "roasty",
+	}
+	tea.ParseSize("50g")
+	tea.Add(hgtealib.Entry{Tea: 1, DateTime: time.Date(2018, 3, 1, 8, 30, 0, 0, time.UTC), Rating: 3, SessionInstance: "A"})
+
+	data, _ := json.Marshal(newTeaJson(tea))
+	fmt.Println(string(data))
+
+	// The optional fields of a tea which does not have them
+	data, _ = json.Marshal(newTeaJson(hgtealib.Tea{Id: 2, Name: "Sencha"}))
+	fmt.Println(string(data))
+
+	// A purchase date which was not recognized is kept as text
+	sample := hgtealib.Tea{Id: 3, Name: "Bai Mu Dan"}
+	sample.Purchased.ParseDate("last spring")
+	data, _ = json.Marshal(newTeaJson(sample).Purchased)
+	fmt.Println(string(data))
+
+	// Output:
+	// {"id":1,"name":"Dong Ding","type":"Oolong","year":2017,"flush":"Second","origin":{"country":"Taiwan","region":"Nantou"},"size":"50g","quantity":50,"grade":"FTGFOP1","stocked":true,"aging":false,"purchased":{"location":"Online","date":"2018-02-03T00:00:00Z","price":12.5,"currency":"USD","packaging":"LooseLeaf"},"ratings":{"Value":3},"blend":[{"tea":2,"ratio":1}],"comments":"roasty","stats":{"entries":1,"sessions":1,"average":3,"median":3,"mode":3,"costPerSession":12.5}}
+	// {"id":2,"name":"Sencha","type":"","year":0,"flush":"","origin":{"country":"","region":""},"size":"","quantity":0,"grade":"","stocked":false,"aging":false,"purchased":{"location":"","date":null,"price":0,"currency":"","packaging":"LooseLeaf"},"ratings":{},"blend":[],"stats":{"entries":0,"sessions":0,"average":0,"median":0,"mode":0,"costPerSession":0}}
+	// {"location":"","date":null,"dateText":"last spring","price":0,"currency":"","packaging":"LooseLeaf"}
+}
+
+func Example_newEntryJson() {
+	teas := []*hgtealib.Tea{{Id: 1, Name: "Dong Ding", Picked: hgtealib.TeaPickPeriod{Year: 2017}}}
+	entry := hgtealib.Entry{Tea: 1, DateTime: time.Date(2018, 3, 1, 8, 30, 0, 0, time.UTC), Rating: 3, Comments: "toasty #evening",
+		SteepTime: 150 * time.Second, SteepingVessel: hgtealib.Gaiwan, SteepingTemperature: 195, SessionInstance: "ABC",
+		Fixins: []hgtealib.TeaFixin{hgtealib.Honey}, LeafGrams: 5, WaterMl: 100}
+	entry.ParseTags()
+	db, _ := hgtealib.NewTeaDb(nil, teas, []*hgtealib.Entry{&entry})
+
+	data, _ := json.Marshal(newEntryJson(db, entry))
+	fmt.Println(string(data))
+
+	// The optional fields of an entry which does not have them, including the name of an unknown tea
+	data, _ = json.Marshal(newEntryJson(db, hgtealib.Entry{Tea: 9, DateTime: time.Date(2018, 3, 2, 9, 0, 0, 0, time.UTC)}))
+	fmt.Println(string(data))
+
+	// Output:
+	// {"tea":1,"teaName":"2017 Dong Ding","time":"2018-03-01T08:30:00Z","rating":3,"comments":"toasty #evening","steepSeconds":150,"vessel":"Gaiwan","vesselType":"Gaiwan","temperature":195,"session":"ABC","fixins":["Honey"],"leafGrams":5,"waterMl":100,"ratio":5,"tags":["evening","roasted"]}
+	// {"tea":9,"teaName":"","time":"2018-03-02T09:00:00Z","rating":0,"comments":"","steepSeconds":0,"vessel":"French Press","vesselType":"French Press","temperature":0,"session":"","fixins":[],"leafGrams":0,"waterMl":0,"ratio":0,"tags":[]}
+}
@@ -7,38 +7,93 @@ import (
 	"flag"
 	"fmt"
 	"gitlab.com/hokiegeek/hgtealib"
+	"golang.org/x/term"
+	"io"
 	"log"
 	"os"
 	"os/user"
 	"path"
 	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"text/template"
 	"time"
 )
 
 type viewOptions struct {
 	delimeter string
 	porcelain bool
+	format    string
+	template  *template.Template
+	width     int
+	color     bool
+	last      int
 	fields    []string
+	sort      []string
 }
 
 type options struct {
 	Delimeter string              `json:"delimeter"`
 	Porcelain bool                `json:"porcelain"`
+	Format    string              `json:"format"`
 	Fields    map[string][]string `json:"fields"`
 	DbCfg     struct {
 		DbType     string `json:"dbType"`
 		TeasUrl    string `json:"teasUrl"`
 		JournalUrl string `json:"journalUrl"`
+		VesselsUrl string `json:"vesselsUrl"`
+		FixinsUrl  string `json:"fixinsUrl"`
+		Path       string `json:"path"`
 	} `json:"dbCfg"`
-	Proxy   string           `json:"proxy"`
-	filter  *hgtealib.Filter `json:"-"`
-	command string           `json:"-"`
+	Proxy          string             `json:"proxy"`
+	ProductRatings []string           `json:"productRatings"`
+	Currency       string             `json:"currency"`
+	LeafPerSession map[string]float64 `json:"leafPerSession"`
+	AgingTargets   map[string]float64 `json:"agingTargets"`
+	FlushNaming    map[string]string  `json:"flushNaming"`
+	Vessels        []struct {
+		Id     int    `json:"id"`
+		Name   string `json:"name"`
+		Volume int    `json:"volume"`
+	} `json:"vessels"`
+	Fixins []struct {
+		Id       int    `json:"id"`
+		Name     string `json:"name"`
+		Category string `json:"category"`
+	} `json:"fixins"`
+	VesselInventory []struct {
+		Name      string   `json:"name"`
+		Type      string   `json:"type"`
+		Material  string   `json:"material"`
+		Volume    int      `json:"volume"`
+		Dedicated []string `json:"dedicated"`
+	} `json:"vesselInventory"`
+	TeaTypes []struct {
+		Name    string   `json:"name"`
+		Parent  string   `json:"parent"`
+		Aliases []string `json:"aliases"`
+	} `json:"teaTypes"`
+	Flavors []struct {
+		Name     string   `json:"name"`
+		Category string   `json:"category"`
+		Terms    []string `json:"terms"`
+	} `json:"flavors"`
+	sort     []string           `json:"-"`
+	groupBy  string             `json:"-"`
+	grouped  bool               `json:"-"`
+	top      int                `json:"-"`
+	level    int                `json:"-"`
+	last     int                `json:"-"`
+	filter   *hgtealib.Filter   `json:"-"`
+	template *template.Template `json:"-"`
+	command  string             `json:"-"`
 }
 
 func newOptions() *options {
 	o := new(options)
 	o.Delimeter = "\t"
+	o.Format = "text"
 
 	o.DbCfg.DbType = "tsv"
 	o.DbCfg.TeasUrl = "https://docs.google.com/spreadsheets/d/1-U45bMxRE4_n3hKRkTPTWHTkVKC8O3zcSmkjEyYFYOo/pub?output=tsv"
@@ -47,143 +102,766 @@ func newOptions() *options {
 	o.Fields = make(map[string][]string)
 	o.Fields["ls"] = []string{"Id", "Name", "Type", "Year", "Flush", "Origin", "Entries", "Avg", "Median", "Mode"}
 	o.Fields["log"] = []string{"Time", "Tea", "Steep Time", "Rating", "Fixins", "Vessel"}
+	o.Fields["show"] = []string{"Time", "Steep Time", "Rating", "Fixins", "Vessel", "Temp", "Comments"}
+	o.Fields["spend"] = []string{"Group", "Teas", "Total"}
+	o.Fields["vessels"] = []string{"Vessel", "Type", "Material", "Volume", "Entries", "Avg", "Teas"}
+	o.Fields["add"] = []string{"Time", "Tea", "Steep Time", "Rating", "Fixins", "Vessel", "Temp", "Session"}
+	o.Fields["tags"] = []string{"Tag", "Category", "Entries", "Teas", "Avg", "Ratings"}
+	o.Fields["search"] = []string{"Score", "Date", "Tea", "Rating", "Field", "Snippet"}
+	o.Fields["inventory"] = []string{"Id", "Name", "Size", "Sessions", "Used", "Remaining", "Days"}
 	return o
 }
 
 func printHeader(fields map[string]string, opts viewOptions) {
-	re_lcalpha := regexp.MustCompile("[a-z]+")
-	re_dashnums := regexp.MustCompile("-?[0-9]+")
+	writeHeader(os.Stdout, fields, opts)
+}
+
+func writeHeader(w io.Writer, fields map[string]string, opts viewOptions) {
+	re_lcalpha := regexp.MustCompile("(\\.[0-9]+)?[a-z]+")
 	for i, field := range opts.fields {
 		if opts.porcelain {
-			fields[field] = re_dashnums.ReplaceAllString(fields[field], "")
+			fields[field] = re_width.ReplaceAllString(fields[field], "%")
 		} else {
 			if i != 0 {
-				fmt.Print(opts.delimeter)
+				fmt.Fprint(w, opts.delimeter)
 			}
-			fmt.Printf(re_lcalpha.ReplaceAllString(fields[field], "s"), field)
+			fmt.Fprintf(w, re_lcalpha.ReplaceAllString(fields[field], "s"), field)
 			if i == len(opts.fields)-1 {
-				fmt.Println()
+				fmt.Fprintln(w)
 			}
 		}
 	}
 }
 
-func printTeas(teas map[int]hgtealib.Tea, opts viewOptions) {
-	fields := map[string]string{
-		"Id":        "%3d",
-		"Name":      "%-60s",
-		"Type":      "%-15s",
-		"Year":      "%d",
-		"Flush":     "%9s",
-		"Origin":    "%30s",
-		"Size":      "%12s",
-		"Entries":   "%7d",
-		"Avg":       "%6d",
-		"Median":    "%6d",
-		"Mode":      "%6d",
-		"Packaging": "%10s",
-		// Storage.Stocked bool
-		// Storage.Aging   bool
-		// Purchased.Location  string
-		// Purchased.Date      string
-		// Purchased.Price     float64
-		// LeafGrade     string
+func lessField(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a == nil && b != nil
 	}
 
-	printHeader(fields, opts)
+	switch v := a.(type) {
+	case int:
+		return v < b.(int)
+	case float64:
+		return v < b.(float64)
+	case bool:
+		return !v && b.(bool)
+	case hgtealib.TeaFlush:
+		return v.Flush < b.(hgtealib.TeaFlush).Flush
+	case string:
+		return strings.ToLower(v) < strings.ToLower(b.(string))
+	default:
+		return false
+	}
+}
 
-	// Now print the teas
+func sortTeas(teas map[int]hgtealib.Tea, fields []string) []hgtealib.Tea {
+	sorted := make([]hgtealib.Tea, 0)
 	for _, tea := range teas {
-		for i, field := range opts.fields {
-			if i != 0 {
-				fmt.Print(opts.delimeter)
+		sorted = append(sorted, tea)
+	}
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		for _, field := range append(fields, "Id") {
+			descending := strings.HasPrefix(field, "-")
+			field = strings.TrimPrefix(field, "-")
+
+			column, ok := lookupTeaColumn(field)
+			if !ok {
+				continue
+			}
+
+			a, b := column.value(sorted[i]), column.value(sorted[j])
+			if descending {
+				a, b = b, a
+			}
+
+			if lessField(a, b) {
+				return true
+			} else if lessField(b, a) {
+				return false
+			}
+		}
+		return false
+	})
+
+	return sorted
+}
+
+func teasTable(teas map[int]hgtealib.Tea, opts viewOptions) table {
+	t := newTable("", opts.fields, map[string]string{})
+	for _, field := range opts.fields {
+		if column, ok := lookupTeaColumn(field); ok {
+			t.formats[field] = column.format
+			t.headers[field] = column.header
+		}
+	}
+	t.rate(append([]string{"Avg", "Median", "Mode"}, hgtealib.TeaProductRatings...)...)
+
+	for _, tea := range sortTeas(teas, opts.sort) {
+		t.addRow(func(field string) interface{} {
+			if column, ok := lookupTeaColumn(field); ok {
+				return column.value(tea)
 			}
+			return nil
+		})
+	}
+
+	return t
+}
+
+func statsTable(stats map[string]hgtealib.Stats, opts viewOptions) table {
+	t := newTable("", opts.fields, map[string]string{
+		"Group":   "%-30s",
+		"Teas":    "%5d",
+		"Entries": "%7d",
+		"Avg":     "%6.2f",
+		"Median":  "%6d",
+		"Mode":    "%6d",
+		"Ratio":   "%5.1f",
+	})
+	for _, rating := range hgtealib.TeaProductRatings {
+		t.formats[rating] = fmt.Sprintf("%%%d.2f", len(rating))
+	}
+	t.rate(append([]string{"Avg", "Median", "Mode"}, hgtealib.TeaProductRatings...)...)
+
+	groups := make([]string, 0)
+	for g := range stats {
+		groups = append(groups, g)
+	}
+	sort.Strings(groups)
+
+	for _, g := range groups {
+		s := stats[g]
+		t.addRow(func(field string) interface{} {
 			switch {
-			case field == "Id":
-				fmt.Printf(fields[field], tea.Id)
-			case field == "Name":
-				fmt.Printf(fields[field], tea.Name)
-			case field == "Type":
-				fmt.Printf(fields[field], tea.Type)
-			case field == "Year":
-				if tea.Picked.Year == 0 {
-					fmt.Printf("%s", "")
-				} else {
-					fmt.Printf(fields[field], tea.Picked.Year)
-				}
-			case field == "Flush":
-				fmt.Printf(fields[field], tea.Picked.Flush)
-			case field == "Origin":
-				fmt.Printf(fields[field], tea.Origin.String())
-			case field == "Size":
-				fmt.Printf(fields[field], tea.Size)
+			case field == "Group":
+				return g
+			case field == "Teas":
+				return s.Teas
 			case field == "Entries":
-				fmt.Printf(fields[field], tea.LogLen())
+				return s.Entries
 			case field == "Avg":
-				fmt.Printf(fields[field], tea.Average())
+				return s.Average
 			case field == "Median":
-				fmt.Printf(fields[field], tea.Median())
+				return s.Median
 			case field == "Mode":
-				fmt.Printf(fields[field], tea.Mode())
-			case field == "Packaging":
-				fmt.Printf(fields[field], tea.Purchased.Packaging.String())
+				return s.Mode
+			case field == "Ratio":
+				return s.Ratio
+			default:
+				return s.Ratings[field]
 			}
+		})
+	}
+
+	return t
+}
+
+func formatTotals(totals map[string]float64) string {
+	currencies := make([]string, 0)
+	for c := range totals {
+		currencies = append(currencies, c)
+	}
+	sort.Strings(currencies)
+
+	var buf bytes.Buffer
+	for i, c := range currencies {
+		if i != 0 {
+			buf.WriteString(", ")
 		}
-		fmt.Println()
+		buf.WriteString(hgtealib.FormatPrice(totals[c], c))
 	}
+	return buf.String()
 }
 
-func printEntries(db *hgtealib.TeaDb, log []hgtealib.Entry, opts viewOptions) {
-	fields := map[string]string{
-		"Time":       "%-21s",
-		"Tea":        "%-60s",
-		"Steep Time": "%10s",
-		"Rating":     "%d",
-		"Fixins":     "%-25s",
-		"Vessel":     "%-15s",
-		"Temp":       "%d°",
-		"Session":    "%-35s",
-		"Comments":   "%s",
+func spendTable(title string, spend map[string]hgtealib.Spend, opts viewOptions) table {
+	t := newTable(title, opts.fields, map[string]string{
+		"Group": "%-30s",
+		"Teas":  "%5d",
+		"Total": "%20s",
+	})
+
+	groups := make([]string, 0)
+	for g := range spend {
+		groups = append(groups, g)
 	}
+	sort.Strings(groups)
 
-	printHeader(fields, opts)
+	for _, g := range groups {
+		t.addRow(func(field string) interface{} {
+			switch {
+			case field == "Group":
+				return g
+			case field == "Teas":
+				return spend[g].Teas
+			case field == "Total":
+				return formatTotals(spend[g].Totals)
+			}
+			return nil
+		})
+	}
 
-	for _, v := range log {
-		tea, _ := db.Tea(v.Tea)
-		for i, field := range opts.fields {
-			if i != 0 {
-				fmt.Print(opts.delimeter)
+	return t
+}
+
+func costPerSessionTable(teas map[int]hgtealib.Tea) table {
+	t := newTable("Cost per session", []string{"Tea", "Entries", "Cost"}, map[string]string{
+		"Tea":     "%-60s",
+		"Entries": "%7d",
+		"Cost":    "%12s",
+	})
+
+	sorted := sortTeas(teas, nil)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].CostPerSession() > sorted[j].CostPerSession()
+	})
+
+	for _, tea := range sorted {
+		if tea.CostPerSession() == 0 {
+			continue
+		}
+		t.addRow(func(field string) interface{} {
+			switch {
+			case field == "Tea":
+				return tea.String()
+			case field == "Entries":
+				return tea.LogLen()
+			case field == "Cost":
+				return hgtealib.FormatPrice(tea.CostPerSession(), tea.Purchased.Currency)
+			}
+			return nil
+		})
+	}
+
+	return t
+}
+
+func inventoryTables(teas map[int]hgtealib.Tea, inventory map[int]hgtealib.TeaInventory, opts viewOptions) []table {
+	t := newTable("", opts.fields, map[string]string{
+		"Id":        "%3d",
+		"Name":      "%-60s",
+		"Size":      "%12s",
+		"Sessions":  "%8d",
+		"Used":      "%7.1f",
+		"Remaining": "%9.1f",
+		"Days":      "%6s",
+	})
+	finished := newTable("Likely finished but still marked as stocked", []string{"Id", "Name"}, map[string]string{
+		"Id":   "%3d",
+		"Name": "%s",
+	})
+
+	for _, tea := range sortTeas(teas, opts.sort) {
+		i, ok := inventory[tea.Id]
+		if !ok || i.Quantity == 0 {
+			continue
+		}
+		if i.Finished {
+			finished.addRow(func(field string) interface{} {
+				return map[string]interface{}{"Id": tea.Id, "Name": tea.String()}[field]
+			})
+		}
+
+		t.addRow(func(field string) interface{} {
+			switch {
+			case field == "Id":
+				return tea.Id
+			case field == "Name":
+				return tea.String()
+			case field == "Size":
+				return tea.Size
+			case field == "Sessions":
+				return tea.Sessions()
+			case field == "Used":
+				return i.Used
+			case field == "Remaining":
+				return i.Remaining
+			case field == "Days":
+				if days := i.DaysLeft(); days >= 0 {
+					return strconv.Itoa(int(days))
+				}
 			}
+			return nil
+		})
+	}
+
+	if len(finished.rows) == 0 || opts.porcelain {
+		return []table{t}
+	}
+	return []table{t, finished}
+}
+
+func agingTables(teas map[int]hgtealib.Tea, opts viewOptions) []table {
+	now := time.Now()
+
+	sorted := sortTeas(teas, opts.sort)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, _ := sorted[i].AgeAt(now)
+		b, _ := sorted[j].AgeAt(now)
+		return a > b
+	})
+
+	byAge := newTable("Teas by age", []string{"Tea", "Packaging", "Age", "Target"}, map[string]string{
+		"Tea":       "%-60s",
+		"Packaging": "%10s",
+		"Age":       "%5.1f",
+		"Target":    "%s",
+	})
+	for _, tea := range sorted {
+		age, ok := tea.AgeAt(now)
+		if !ok {
+			continue
+		}
+		byAge.addRow(func(field string) interface{} {
 			switch {
-			case field == "Time":
-				fmt.Printf(fields[field], v.DateTime.Format(time.RFC822Z))
 			case field == "Tea":
-				fmt.Printf(fields[field], tea.String())
-			case field == "Steep Time":
-				fmt.Printf(fields[field], v.SteepTime)
-			case field == "Rating":
-				fmt.Printf(fields[field], v.Rating)
-			case field == "Fixins":
-				var buf bytes.Buffer
-				for i, f := range v.Fixins {
-					if i != 0 {
-						buf.WriteString(", ")
-					}
-					buf.WriteString(f.String())
+				return tea.String()
+			case field == "Packaging":
+				return tea.Purchased.Packaging.String()
+			case field == "Age":
+				return age
+			case field == "Target":
+				if tea.ReachedAgingTarget(now) {
+					return fmt.Sprintf("reached %.0f year target", tea.AgingTarget())
 				}
-				fmt.Printf(fields[field], buf.String())
+			}
+			return nil
+		})
+	}
+
+	tables := []table{byAge}
+	for _, tea := range sorted {
+		ratings := tea.RatingsByAge()
+		if len(ratings) == 0 {
+			continue
+		}
+
+		t := newTable(tea.String(), []string{"Age", "Rating", "Avg", "Entries"}, map[string]string{
+			"Age":     "%3dy",
+			"Rating":  "%-20s",
+			"Avg":     "%.2f",
+			"Entries": "%d",
+		})
+		t.rate("Avg")
+		for _, r := range ratings {
+			t.addRow(func(field string) interface{} {
+				switch {
+				case field == "Age":
+					return r.Age
+				case field == "Rating":
+					return strings.Repeat("#", int(r.Average*5+0.5))
+				case field == "Avg":
+					return r.Average
+				case field == "Entries":
+					return r.Entries
+				}
+				return nil
+			})
+		}
+		tables = append(tables, t)
+	}
+
+	return tables
+}
+
+func vesselsTable(use map[string]hgtealib.VesselUse, opts viewOptions) table {
+	t := newTable("", opts.fields, map[string]string{
+		"Vessel":   "%-25s",
+		"Type":     "%-15s",
+		"Material": "%-20s",
+		"Volume":   "%6d",
+		"Entries":  "%7d",
+		"Avg":      "%6.2f",
+		"Teas":     "%s",
+	})
+	t.rate("Avg")
+
+	names := make([]string, 0)
+	for name := range use {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		u := use[name]
+		t.addRow(func(field string) interface{} {
+			switch {
 			case field == "Vessel":
-				fmt.Printf(fields[field], v.SteepingVessel)
-			case field == "Temp":
-				fmt.Printf(fields[field], v.SteepingTemperature)
-			case field == "Session":
-				fmt.Printf(fields[field], v.SessionInstance)
-			case field == "Comments":
-				fmt.Printf(fields[field], v.Comments)
+				return u.Vessel.Name
+			case field == "Type":
+				return u.Vessel.Type.String()
+			case field == "Material":
+				return u.Vessel.Material
+			case field == "Volume":
+				return u.Vessel.Volume
+			case field == "Entries":
+				return u.Entries
+			case field == "Avg":
+				return u.Average
+			case field == "Teas":
+				types := make([]string, 0)
+				for t := range u.Types {
+					types = append(types, t)
+				}
+				sort.Slice(types, func(i, j int) bool {
+					return u.Types[types[i]] > u.Types[types[j]] || (u.Types[types[i]] == u.Types[types[j]] && types[i] < types[j])
+				})
+				mix := make([]string, len(types))
+				for i, t := range types {
+					mix[i] = fmt.Sprintf("%s %d%%", t, u.Types[t]*100/u.Entries)
+				}
+				return strings.Join(mix, ", ")
+			}
+			return nil
+		})
+	}
+
+	return t
+}
+
+func printMisuses(db *hgtealib.TeaDb, use map[string]hgtealib.VesselUse) {
+	names := make([]string, 0)
+	for name := range use {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		u := use[name]
+		for _, e := range u.Misuses {
+			tea, _ := db.Tea(e.Tea)
+			fmt.Fprintf(os.Stderr, "WARNING: %s is dedicated to %s but was used for %s (%s) on %s\n",
+				u.Vessel.Name, strings.Join(u.Vessel.Dedicated, ", "), tea.String(), tea.Type, e.DateTime.Format("2006-01-02"))
+		}
+	}
+}
+
+func printWarnings(db *hgtealib.TeaDb) {
+	for _, w := range db.Warnings() {
+		fmt.Fprintf(os.Stderr, "WARNING: %s\n", w)
+	}
+}
+
+func formatOptional(v float64, format string) interface{} {
+	if v == 0 {
+		return nil
+	}
+	return fmt.Sprintf(format, v)
+}
+
+func entriesTable(db *hgtealib.TeaDb, log []hgtealib.Entry, opts viewOptions) table {
+	t := newTable("", opts.fields, map[string]string{})
+	for _, field := range opts.fields {
+		if column, ok := lookupEntryColumn(field); ok {
+			t.formats[field] = column.format
+			t.headers[field] = column.header
+		}
+	}
+	t.rate("Rating", "Session Avg", "Tea Avg", "Tea Median", "Tea Mode")
+
+	sessions := make(map[string][]hgtealib.Entry)
+	all, _ := db.Log(hgtealib.NewFilter())
+	for _, e := range all {
+		if e.SessionInstance != "" {
+			sessions[e.SessionInstance] = append(sessions[e.SessionInstance], e)
+		}
+	}
+
+	for _, v := range log {
+		row := entryRow{entry: v, session: sessions[v.SessionInstance]}
+		row.tea, _ = db.Tea(v.Tea)
+		if v.SessionInstance == "" {
+			row.session = []hgtealib.Entry{v}
+		}
+
+		t.addRow(func(field string) interface{} {
+			if column, ok := lookupEntryColumn(field); ok {
+				return column.value(row)
+			}
+			return nil
+		})
+	}
+
+	return t
+}
+
+// topTags limits each group to its n most common tags, unless n is negative
+func topTags(tags map[string][]hgtealib.TagStats, n int) map[string][]hgtealib.TagStats {
+	top := make(map[string][]hgtealib.TagStats)
+	for g, stats := range tags {
+		if n >= 0 && len(stats) > n {
+			stats = stats[:n]
+		}
+		top[g] = stats
+	}
+	return top
+}
+
+func formatRatingCounts(counts map[int]int) string {
+	parts := make([]string, 0)
+	for rating := 4; rating >= 0; rating-- {
+		if counts[rating] > 0 {
+			parts = append(parts, fmt.Sprintf("%d:%d", rating, counts[rating]))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// tagsTables has a table of the most common tags of each group, titled with the group when grouped
+func tagsTables(tags map[string][]hgtealib.TagStats, grouped bool, opts viewOptions) []table {
+	tables := make([]table, 0)
+	for _, g := range sortedKeys(tags) {
+		title := ""
+		if grouped {
+			title = g
+			if title == "" {
+				title = "(none)"
+			}
+		}
+		t := newTable(title, opts.fields, map[string]string{
+			"Tag":      "%-20s",
+			"Category": "%-10s",
+			"Entries":  "%7d",
+			"Teas":     "%4d",
+			"Avg":      "%4.2f",
+			"Ratings":  "%-20s",
+		})
+		t.rate("Avg")
+
+		for _, s := range tags[g] {
+			t.addRow(func(field string) interface{} {
+				switch {
+				case field == "Tag":
+					return s.Name
+				case field == "Category":
+					return s.Category
+				case field == "Entries":
+					return s.Entries
+				case field == "Teas":
+					return s.Teas
+				case field == "Avg":
+					return s.Average
+				case field == "Ratings":
+					return formatRatingCounts(s.Ratings)
+				}
+				return nil
+			})
+		}
+		tables = append(tables, t)
+	}
+	return tables
+}
+
+// searchTable has a row for each hit, with the score, field and snippet of the hit along with any of the entry
+// columns. Only the tea columns have a value for the hits on teas rather than on entries.
+func searchTable(db *hgtealib.TeaDb, hits []hgtealib.SearchHit, opts viewOptions) table {
+	t := newTable("", opts.fields, map[string]string{
+		"Score":   "%6.2f",
+		"Field":   "%-17s",
+		"Snippet": "%s",
+	})
+	for _, field := range opts.fields {
+		if column, ok := lookupEntryColumn(field); ok {
+			t.formats[field] = column.format
+			t.headers[field] = column.header
+		}
+	}
+	t.rate("Rating", "Tea Avg", "Tea Median", "Tea Mode")
+
+	for _, hit := range hits {
+		row := entryRow{}
+		row.tea, _ = db.Tea(hit.Tea)
+		if hit.Entry != nil {
+			row.entry = *hit.Entry
+			row.session = []hgtealib.Entry{*hit.Entry}
+		}
+
+		t.addRow(func(field string) interface{} {
+			switch {
+			case field == "Score":
+				return hit.Score
+			case field == "Field":
+				return hit.Field
+			case field == "Snippet":
+				return highlighted{hit.Snippet, hit.Highlights}
+			}
+			if column, ok := lookupEntryColumn(field); ok && (hit.Entry != nil || field == "Tea" || strings.HasPrefix(field, "Tea ")) {
+				return column.value(row)
+			}
+			return nil
+		})
+	}
+
+	return t
+}
+
+func lastEntries(tea hgtealib.Tea, n int) []hgtealib.Entry {
+	log := tea.Log()
+	if n >= 0 && len(log) > n {
+		log = log[len(log)-n:]
+	}
+	return log
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+func printTea(db *hgtealib.TeaDb, tea hgtealib.Tea, opts viewOptions) {
+	now := time.Now()
+
+	fmt.Printf("%-12s %d\n", "Id:", tea.Id)
+	fmt.Printf("%-12s %s\n", "Name:", tea.String())
+	fmt.Printf("%-12s %s\n", "Type:", tea.Type)
+	fmt.Printf("%-12s %s\n", "Origin:", tea.Origin.String())
+	if tea.Picked.Year != 0 {
+		fmt.Printf("%-12s %d\n", "Year:", tea.Picked.Year)
+	}
+	fmt.Printf("%-12s %s\n", "Flush:", tea.Picked.Flush)
+	fmt.Printf("%-12s %s\n", "Size:", tea.Size)
+	if tea.Quantity > 0 {
+		fmt.Printf("%-12s %.1fg\n", "Quantity:", tea.Quantity)
+	}
+	fmt.Printf("%-12s %.1fg\n", "Leaf:", tea.SessionLeaf())
+	if tea.LeafGrade.Raw != "" {
+		fmt.Printf("%-12s %s (%s)\n", "Grade:", tea.LeafGrade.Code(), tea.LeafGrade.Description())
+	}
+	if tea.Comments != "" {
+		fmt.Printf("%-12s %s\n", "Comments:", tea.Comments)
+	}
+
+	if tea.IsBlend() {
+		fmt.Println("Blend:")
+		shares := tea.Blend.Shares()
+		for _, c := range tea.Blend {
+			component, _ := db.Tea(c.Tea)
+			fmt.Printf("  %5.1f%%  %3d  %s\n", shares[c.Tea]*100, c.Tea, component.String())
+		}
+	}
+
+	fmt.Println()
+	fmt.Println("Purchase")
+	if tea.Purchased.Location != "" {
+		fmt.Printf("  %-10s %s\n", "From:", tea.Purchased.Location)
+	}
+	if date := tea.Purchased.DateString(); date != "" {
+		fmt.Printf("  %-10s %s\n", "Date:", date)
+	}
+	if tea.Purchased.Price != 0 {
+		fmt.Printf("  %-10s %s\n", "Price:", hgtealib.FormatPrice(tea.Purchased.Price, tea.Purchased.Currency))
+	}
+	fmt.Printf("  %-10s %s\n", "Packaging:", tea.Purchased.Packaging)
+
+	fmt.Println()
+	fmt.Println("Storage")
+	fmt.Printf("  %-10s %s\n", "Stocked:", yesNo(tea.Storage.Stocked))
+	fmt.Printf("  %-10s %s\n", "Aging:", yesNo(tea.Storage.Aging))
+	if age, ok := tea.AgeAt(now); ok {
+		fmt.Printf("  %-10s %.1f years", "Age:", age)
+		if target := tea.AgingTarget(); tea.Storage.Aging && target > 0 {
+			fmt.Printf(" of %.0f", target)
+		}
+		fmt.Println()
+	}
+
+	fmt.Println()
+	fmt.Println("Ratings")
+	fmt.Printf("  %-10s %d\n", "Entries:", tea.LogLen())
+	fmt.Printf("  %-10s %d\n", "Sessions:", tea.Sessions())
+	fmt.Printf("  %-10s %.2f\n", "Consumed:", db.Consumption()[tea.Id])
+	fmt.Printf("  %-10s %d\n", "Average:", tea.Average())
+	fmt.Printf("  %-10s %d\n", "Median:", tea.Median())
+	fmt.Printf("  %-10s %d\n", "Mode:", tea.Mode())
+	for _, rating := range hgtealib.TeaProductRatings {
+		if r, ok := tea.Ratings[rating]; ok {
+			fmt.Printf("  %-10s %d\n", rating+":", r)
+		}
+	}
+	if tea.LogLen() > 0 {
+		counts := tea.RatingCounts()
+		var most int
+		for _, count := range counts {
+			if count > most {
+				most = count
 			}
 		}
+		for rating := 4; rating >= 0; rating-- {
+			fmt.Printf("  %d %-20s %d\n", rating, strings.Repeat("#", counts[rating]*20/most), counts[rating])
+		}
+	}
+
+	if r, ok := tea.Recommendation(); ok {
+		fmt.Println()
+		fmt.Println("Favorite steeping")
+		fmt.Printf("  %-10s %s\n", "Time:", r.SteepTime)
+		fmt.Printf("  %-10s %d°\n", "Temp:", r.Temperature)
+		fmt.Printf("  %-10s %s\n", "Vessel:", r.Vessel)
+		if r.Ratio > 0 {
+			fmt.Printf("  %-10s %.1fg per 100ml\n", "Ratio:", r.Ratio)
+		}
+		fmt.Printf("  %-10s rated %d in %d entries\n", "From:", r.Rating, r.Entries)
+	}
+
+	if log := lastEntries(tea, opts.last); len(log) > 0 {
 		fmt.Println()
+		render(opts, entriesTable(db, log, opts))
+	}
+}
+
+// databasePath returns the path of a local database, which defaults to a file in the home directory named after
+// the type of database
+func databasePath(opts *options, home string) string {
+	dbPath := opts.DbCfg.Path
+	switch {
+	case dbPath == "" && opts.DbCfg.DbType == "sqlite":
+		dbPath = path.Join(home, ".hgteas.db")
+	case dbPath == "":
+		dbPath = path.Join(home, ".hgteas.db.json")
+	case strings.HasPrefix(dbPath, "~/"):
+		dbPath = path.Join(home, dbPath[2:])
+	}
+	return dbPath
+}
+
+// sqlTable holds the rows of a query, whose values are all displayed as they are. The fields default to the columns
+// of the query.
+func sqlTable(columns []string, rows [][]interface{}, opts viewOptions) table {
+	formats := make(map[string]string)
+	for _, c := range columns {
+		formats[c] = "%v"
+	}
+
+	fields := opts.fields
+	if len(fields) == 0 {
+		fields = columns
+	}
+	t := newTable("", fields, formats)
+	for _, row := range rows {
+		t.addRow(func(field string) interface{} {
+			for i, c := range columns {
+				if c == field {
+					return row[i]
+				}
+			}
+			return nil
+		})
 	}
+	return t
+}
+
+// sqlRows maps the values of each row of a query to the names of their columns
+func sqlRows(columns []string, rows [][]interface{}) []map[string]interface{} {
+	views := make([]map[string]interface{}, len(rows))
+	for i, row := range rows {
+		views[i] = make(map[string]interface{})
+		for j, c := range columns {
+			views[i][c] = row[j]
+		}
+	}
+	return views
 }
 
 func parseConfigFile(opts *options, path string) (*options, error) {
@@ -200,16 +878,27 @@ func parseConfigFile(opts *options, path string) (*options, error) {
 }
 
 func parseCommandLineArguments(opts *options) (*options, []string, error) {
-	databaseTypeStr := flag.String("dbType", "", "The type of database that the URLs are pointing to")
+	databaseTypeStr := flag.String("dbType", "", "The type of database: tsv for the sheets that the URLs are pointing to, or file or sqlite for the local database at the path")
 	proxyStr := flag.String("proxy", "", "Use the given proxy")
 
-	teaTypes := flag.String("types", "", "Comma-delimited list of tea types to select")
+	teaTypes := flag.String("types", "", "Comma-delimited list of tea types to select, including their subtypes")
 	stockedFlag := flag.Bool("stocked", false, "Only display stocked teas")
+	agingFlag := flag.Bool("aging", false, "Only display teas that are being aged")
+	gradesStr := flag.String("grades", "", "Comma-delimited list of leaf grade codes, styles or parts to select (i.e.: FTGFOP1,broken,tippy)")
+	ratioStr := flag.String("ratio", "", "Only display log entries which used the given range of grams of leaf per 100ml of water (i.e.: 5-7)")
+	ratingsStr := flag.String("ratings", "", "Comma-delimited list of minimum product ratings to select (i.e.: Value:3,Leaf Aroma:4)")
 	// samplesFlag := flag.Bool("samples", false, "Only display tea samples")
 
 	porcelainFlag := flag.Bool("porcelain", false, "Prints out the data in a highly script consumable way")
+	formatStr := flag.String("format", "", "The output format: text, csv, markdown, html, json or ndjson")
+	templateStr := flag.String("template", "", "A Go template to print each tea, entry or group with (i.e.: '{{.Name}} ({{.Origin}})')")
+	templateFileStr := flag.String("template-file", "", "A file containing a Go template to print each tea, entry or group with")
 	fieldsStr := flag.String("fields", "*", "Comma-delimited list of the fields to display")
-	// sortStr := flag.String("sort", "*", "Comma-delimited list of fields to sort the display by")
+	sortStr := flag.String("sort", "", "Comma-delimited list of fields to sort the display by (prefix with '-' to reverse)")
+	groupByStr := flag.String("by", "Type", "The field to group the stats by")
+	lastInt := flag.Int("last", 5, "The number of the most recent entries to show, or -1 for all of them")
+	topInt := flag.Int("top", 20, "The number of the most common tags to list, or -1 for all of them")
+	levelInt := flag.Int("level", -1, "The level of the tea type taxonomy to group the stats by, where 0 is the top level")
 
 	flag.Parse()
 
@@ -223,11 +912,76 @@ func parseCommandLineArguments(opts *options) (*options, []string, error) {
 
 	opts.Porcelain = *porcelainFlag
 
+	if *formatStr != "" {
+		opts.Format = *formatStr
+	}
+	if _, ok := renderers[opts.Format]; !ok && !isJsonFormat(opts.Format) {
+		return nil, nil, errors.New(fmt.Sprintf("Unrecognized output format: %s", opts.Format))
+	}
+
 	opts.filter = hgtealib.NewFilter()
 	if *stockedFlag {
 		opts.filter.StockedOnly()
 	}
+	if *agingFlag {
+		opts.filter.AgingOnly()
+	}
 	opts.filter.Types(strings.Split(*teaTypes, ","))
+	opts.filter.Grades(strings.Split(*gradesStr, ","))
+	for _, r := range strings.Split(*ratingsStr, ",") {
+		if r == "" {
+			continue
+		}
+		kv := strings.SplitN(r, ":", 2)
+		if len(kv) != 2 {
+			return nil, nil, errors.New(fmt.Sprintf("Invalid product rating filter: %s", r))
+		}
+		min, err := strconv.Atoi(kv[1])
+		if err != nil {
+			return nil, nil, errors.New(fmt.Sprintf("Invalid product rating filter: %s", r))
+		}
+		opts.filter.MinRating(kv[0], min)
+	}
+
+	if *ratioStr != "" {
+		bounds := strings.SplitN(*ratioStr, "-", 2)
+		min, err := strconv.ParseFloat(bounds[0], 64)
+		if err != nil {
+			return nil, nil, errors.New(fmt.Sprintf("Invalid ratio filter: %s", *ratioStr))
+		}
+		var max float64
+		if len(bounds) == 2 && bounds[1] != "" {
+			if max, err = strconv.ParseFloat(bounds[1], 64); err != nil {
+				return nil, nil, errors.New(fmt.Sprintf("Invalid ratio filter: %s", *ratioStr))
+			}
+		}
+		opts.filter.Ratio(min, max)
+	}
+
+	tmpl, err := parseTemplate(*templateStr, *templateFileStr)
+	if err != nil {
+		return nil, nil, err
+	}
+	opts.template = tmpl
+
+	if *sortStr != "" {
+		opts.sort = strings.Split(*sortStr, ",")
+		if err := unknownFields(opts.sort, func(field string) bool {
+			_, ok := lookupTeaColumn(field)
+			return ok
+		}); err != nil {
+			return nil, nil, err
+		}
+	}
+	opts.groupBy = *groupByStr
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == "by" {
+			opts.grouped = true
+		}
+	})
+	opts.top = *topInt
+	opts.level = *levelInt
+	opts.last = *lastInt
 
 	opts.command = flag.Arg(0)
 
@@ -249,33 +1003,343 @@ func main() {
 	if err != nil {
 		panic(err)
 	}
+	if len(opts.ProductRatings) > 0 {
+		hgtealib.TeaProductRatings = opts.ProductRatings
+	}
+	if opts.Currency != "" {
+		hgtealib.DefaultCurrency = opts.Currency
+	}
+	for teaType, grams := range opts.LeafPerSession {
+		hgtealib.DefaultLeafPerSession[teaType] = grams
+	}
+	for teaType, years := range opts.AgingTargets {
+		if teaType == "*" {
+			hgtealib.DefaultAgingTarget = years
+		} else {
+			hgtealib.AgingTargets[teaType] = years
+		}
+	}
+	for place, scheme := range opts.FlushNaming {
+		naming, err := hgtealib.ParseFlushNaming(scheme)
+		if err != nil {
+			log.Fatal(err)
+		}
+		hgtealib.FlushNamings[place] = naming
+	}
+	for _, v := range opts.Vessels {
+		if err := hgtealib.AddVesselType(hgtealib.VesselTypeDefinition{Id: hgtealib.VesselType(v.Id), Name: v.Name, Volume: v.Volume}); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, f := range opts.Fixins {
+		category, err := hgtealib.ParseFixinCategory(f.Category)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := hgtealib.AddTeaFixin(hgtealib.FixinDefinition{Id: hgtealib.TeaFixin(f.Id), Name: f.Name, Category: category}); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, v := range opts.VesselInventory {
+		vesselType, err := hgtealib.ParseVesselType(v.Type)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := hgtealib.AddVessel(hgtealib.Vessel{Name: v.Name, Type: vesselType, Material: v.Material, Volume: v.Volume, Dedicated: v.Dedicated}); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, t := range opts.TeaTypes {
+		if err := hgtealib.TeaTypes.Add(t.Name, t.Parent, t.Aliases...); err != nil {
+			log.Fatal(err)
+		}
+	}
+	for _, f := range opts.Flavors {
+		if err := hgtealib.AddFlavorNote(hgtealib.FlavorNote{Name: f.Name, Category: f.Category, Terms: f.Terms}); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if _, ok := opts.Fields["stats"]; !ok {
+		opts.Fields["stats"] = append([]string{"Group", "Teas", "Entries", "Avg", "Median", "Mode"}, hgtealib.TeaProductRatings...)
+	}
 	opts, _, err = parseCommandLineArguments(opts)
 	if err != nil {
-		panic(err)
+		log.Fatal(err)
+	}
+
+	if opts.DbCfg.VesselsUrl != "" {
+		if err := hgtealib.LoadVesselTypesTsv(opts.DbCfg.VesselsUrl, opts.Proxy); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if opts.DbCfg.FixinsUrl != "" {
+		if err := hgtealib.LoadTeaFixinsTsv(opts.DbCfg.FixinsUrl, opts.Proxy); err != nil {
+			log.Fatal(err)
+		}
 	}
 
 	var db *hgtealib.TeaDb
 	switch opts.DbCfg.DbType {
 	case "tsv":
 		db, err = hgtealib.NewFromTsv(opts.DbCfg.TeasUrl, opts.DbCfg.JournalUrl, opts.Proxy)
+	case "file":
+		db, err = hgtealib.NewFromFile(databasePath(opts, usr.HomeDir))
+	case "sqlite":
+		db, err = hgtealib.NewFromSqlite(databasePath(opts, usr.HomeDir))
+	default:
+		err = errors.New(fmt.Sprintf("Unrecognized database type: %s", opts.DbCfg.DbType))
 	}
 	if err != nil {
 		log.Fatal(err)
 	}
+	printWarnings(db)
 
 	viewOpts := viewOptions{
 		delimeter: opts.Delimeter,
 		porcelain: opts.Porcelain,
+		format:    opts.Format,
 		fields:    opts.Fields[opts.command],
+		sort:      opts.sort,
+		template:  opts.template,
+		last:      opts.last,
+	}
+	if term.IsTerminal(int(os.Stdout.Fd())) {
+		viewOpts.width, _, _ = term.GetSize(int(os.Stdout.Fd()))
+		viewOpts.color = os.Getenv("NO_COLOR") == ""
 	}
 
 	switch opts.command {
 	case "ls":
 		teas, _ := db.Teas(opts.filter)
-		printTeas(teas, viewOpts)
+		switch {
+		case viewOpts.template != nil:
+			printTemplate(newTeaViews(sortTeas(teas, viewOpts.sort)), viewOpts)
+		case isJsonFormat(viewOpts.format):
+			printJson(newTeasJson(sortTeas(teas, viewOpts.sort)), viewOpts)
+		default:
+			render(viewOpts, teasTable(teas, viewOpts))
+		}
 	case "log":
-		log, _ := db.Log(opts.filter)
-		printEntries(db, log, viewOpts)
+		log, _ := db.TeasLog(opts.filter)
+		switch {
+		case viewOpts.template != nil:
+			printTemplate(newEntryViews(db, log), viewOpts)
+		case isJsonFormat(viewOpts.format):
+			printJson(newEntriesJson(db, log), viewOpts)
+		default:
+			render(viewOpts, entriesTable(db, log, viewOpts))
+		}
+	case "stats":
+		group, ok := hgtealib.TeaGroupings[opts.groupBy]
+		if opts.groupBy == "Type" && opts.level >= 0 {
+			group = hgtealib.TeaTypeGrouping(opts.level)
+		}
+		if !ok {
+			log.Fatalf("Unrecognized stats grouping: %s\n", opts.groupBy)
+		}
+		stats, err := db.Stats(opts.filter, group)
+		if err != nil {
+			log.Fatal(err)
+		}
+		switch {
+		case viewOpts.template != nil:
+			printTemplate(newStatsViews(stats), viewOpts)
+		case isJsonFormat(viewOpts.format):
+			printJson(newStatsJson(stats), viewOpts)
+		default:
+			render(viewOpts, statsTable(stats, viewOpts))
+		}
+	case "spend":
+		views := make([]*spendView, 0)
+		records := make([]spendJson, 0)
+		tables := make([]table, 0)
+		for _, by := range []string{"Purchase Month", "Purchase Year", "Vendor", "Type", "Origin"} {
+			spend, err := db.Spend(opts.filter, hgtealib.TeaGroupings[by])
+			if err != nil {
+				log.Fatal(err)
+			}
+			views = append(views, newSpendViews(by, spend)...)
+			records = append(records, newSpendJson(by, spend)...)
+			tables = append(tables, spendTable(by, spend, viewOpts))
+		}
+		switch {
+		case viewOpts.template != nil:
+			printTemplate(views, viewOpts)
+		case isJsonFormat(viewOpts.format):
+			printJson(records, viewOpts)
+		default:
+			teas, _ := db.Teas(opts.filter)
+			render(viewOpts, append(tables, costPerSessionTable(teas))...)
+		}
+	case "inventory":
+		teas, _ := db.Teas(opts.filter)
+		inventory, err := db.Inventory(opts.filter, time.Now())
+		if err != nil {
+			log.Fatal(err)
+		}
+		switch {
+		case viewOpts.template != nil:
+			printTemplate(newInventoryViews(sortTeas(teas, viewOpts.sort), inventory), viewOpts)
+		case isJsonFormat(viewOpts.format):
+			printJson(newInventoryJson(sortTeas(teas, viewOpts.sort), inventory), viewOpts)
+		default:
+			render(viewOpts, inventoryTables(teas, inventory, viewOpts)...)
+		}
+	case "aging":
+		teas, _ := db.Teas(opts.filter.AgingOnly())
+		switch {
+		case viewOpts.template != nil:
+			printTemplate(newAgingViews(sortTeas(teas, viewOpts.sort), time.Now()), viewOpts)
+		case isJsonFormat(viewOpts.format):
+			printJson(newAgingJson(sortTeas(teas, viewOpts.sort), time.Now()), viewOpts)
+		default:
+			render(viewOpts, agingTables(teas, viewOpts)...)
+		}
+	case "vessels":
+		use, err := db.VesselUse(opts.filter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		switch {
+		case viewOpts.template != nil:
+			printTemplate(newVesselViews(use), viewOpts)
+		case isJsonFormat(viewOpts.format):
+			printJson(newVesselsJson(db, use), viewOpts)
+		default:
+			render(viewOpts, vesselsTable(use, viewOpts))
+			printMisuses(db, use)
+		}
+	case "show":
+		name := strings.Join(flag.Args()[1:], " ")
+		if name == "" {
+			log.Fatal("Expected the id or name of a tea to show")
+		}
+		tea, err := db.FindTea(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		switch {
+		case viewOpts.template != nil:
+			printTemplate(newTeaDetailView(db, tea, viewOpts.last), viewOpts)
+		case isJsonFormat(viewOpts.format):
+			printJson(newTeaDetailJson(db, tea, viewOpts.last), viewOpts)
+		default:
+			printTea(db, tea, viewOpts)
+		}
+	case "add":
+		if db.ReadOnly() {
+			log.Fatalf("Cannot add entries to the read-only %s database\n", opts.DbCfg.DbType)
+		}
+		addOpts, err := parseAddArguments(flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		var p *prompter
+		if addOpts.interactive || (addOpts.missing() && term.IsTerminal(int(os.Stdin.Fd()))) {
+			p = newPrompter(os.Stdin, os.Stderr)
+		}
+		entry, err := newEntryFromAddOptions(db, addOpts, time.Now().Truncate(time.Second), p)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := db.AddEntry(entry); err != nil {
+			log.Fatal(err)
+		}
+		switch {
+		case viewOpts.template != nil:
+			printTemplate(newEntryViews(db, []hgtealib.Entry{entry}), viewOpts)
+		case isJsonFormat(viewOpts.format):
+			printJson(newEntriesJson(db, []hgtealib.Entry{entry}), viewOpts)
+		default:
+			render(viewOpts, entriesTable(db, []hgtealib.Entry{entry}, viewOpts))
+		}
+	case "tags":
+		var group hgtealib.TeaGrouping
+		if opts.grouped {
+			var ok bool
+			if group, ok = hgtealib.TeaGroupings[opts.groupBy]; !ok {
+				log.Fatalf("Unrecognized tags grouping: %s\n", opts.groupBy)
+			}
+			if opts.groupBy == "Type" && opts.level >= 0 {
+				group = hgtealib.TeaTypeGrouping(opts.level)
+			}
+		}
+		tags, err := db.Tags(opts.filter, group)
+		if err != nil {
+			log.Fatal(err)
+		}
+		tags = topTags(tags, opts.top)
+		switch {
+		case viewOpts.template != nil:
+			printTemplate(newTagViews(tags), viewOpts)
+		case isJsonFormat(viewOpts.format):
+			printJson(newTagsJson(tags), viewOpts)
+		default:
+			render(viewOpts, tagsTables(tags, opts.grouped, viewOpts)...)
+		}
+	case "search":
+		query := strings.Join(flag.Args()[1:], " ")
+		if strings.TrimSpace(query) == "" {
+			log.Fatal("Expected the words to search for")
+		}
+		hits, err := db.Search(query, opts.filter)
+		if err != nil {
+			log.Fatal(err)
+		}
+		switch {
+		case viewOpts.template != nil:
+			printTemplate(newSearchViews(db, hits), viewOpts)
+		case isJsonFormat(viewOpts.format):
+			printJson(newSearchJson(db, hits), viewOpts)
+		default:
+			render(viewOpts, searchTable(db, hits, viewOpts))
+		}
+	case "import":
+		if opts.DbCfg.DbType != "sqlite" {
+			log.Fatalf("Cannot import the sheets into the %s database\n", opts.DbCfg.DbType)
+		}
+		src, err := hgtealib.NewFromTsv(opts.DbCfg.TeasUrl, opts.DbCfg.JournalUrl, opts.Proxy)
+		if err != nil {
+			log.Fatal(err)
+		}
+		printWarnings(src)
+		dbPath := databasePath(opts, usr.HomeDir)
+		if err := hgtealib.ImportSqlite(dbPath, src); err != nil {
+			log.Fatal(err)
+		}
+		teas, _ := src.Teas(hgtealib.NewFilter())
+		entries, _ := src.Log(hgtealib.NewFilter())
+		fmt.Printf("Imported %d teas and %d entries into %s\n", len(teas), len(entries), dbPath)
+	case "sql":
+		if opts.DbCfg.DbType != "sqlite" {
+			log.Fatalf("Cannot query the %s database\n", opts.DbCfg.DbType)
+		}
+		query := strings.Join(flag.Args()[1:], " ")
+		if strings.TrimSpace(query) == "" {
+			log.Fatal("Expected the query to run")
+		}
+		columns, rows, err := hgtealib.QuerySqlite(databasePath(opts, usr.HomeDir), query)
+		if err != nil {
+			log.Fatal(err)
+		}
+		switch {
+		case viewOpts.template != nil:
+			printTemplate(sqlRows(columns, rows), viewOpts)
+		case isJsonFormat(viewOpts.format):
+			printJson(sqlRows(columns, rows), viewOpts)
+		default:
+			render(viewOpts, sqlTable(columns, rows, viewOpts))
+		}
+	case "export":
+		exportOpts, err := parseExportArguments(flag.Args()[1:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		paths, err := exportTsv(db, exportOpts.dir)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("Exported the teas to %s and the journal to %s\n", paths[0], paths[1])
 	default:
 		log.Fatalf("Unrecognized command: %s\n", opts.command)
 	}
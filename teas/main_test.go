@@ -1,5 +1,11 @@
 package main
 
+import (
+	"fmt"
+	"gitlab.com/hokiegeek/hgtealib"
+	"os"
+)
+
 func ExamplePrintHeader() {
 	testFields := map[string]string{
 		"T0": "%40s",
@@ -18,6 +24,20 @@ func ExamplePrintHeader() {
 	// Output: T1   T2  T3
 }
 
+func Example_writeHeaderPorcelain() {
+	testFields := map[string]string{
+		"Avg":   "%6.2f",
+		"Name":  "%-20s",
+		"Count": "%3d",
+	}
+	testOpts := viewOptions{fields: []string{"Avg", "Name", "Count"}, porcelain: true}
+
+	writeHeader(os.Stdout, testFields, testOpts)
+	fmt.Printf(testFields["Avg"]+"|"+testFields["Name"]+"|"+testFields["Count"]+"\n", 19.99, "Dong Ding", 12)
+
+	// Output: 19.99|Dong Ding|12
+}
+
 func ExamplePrintTeas() {
 	// fields = []string{"Id", "Name", "Type", "Year", "Flush", "Origin", "Entries", "Avg", "Median", "Mode"}
 	// printTeas(TODO)
@@ -27,3 +47,54 @@ func ExamplePrintEntries() {
 	// fields = []string{"Time", "Tea", "Steep Time", "Rating", "Fixins", "Vessel", "Temp", "Session", "Comments"}
 	// printEntries(TODO)
 }
+
+func Example_tagsTables() {
+	tags := map[string][]hgtealib.TagStats{
+		"Oolong": {
+			{Tag: hgtealib.Tag{Name: "roasted", Category: "Roasted"}, Entries: 3, Teas: 2, Average: 3, Ratings: map[int]int{4: 1, 3: 1, 2: 1}},
+			{Tag: hgtealib.Tag{Name: "morning"}, Entries: 1, Teas: 1, Average: 4, Ratings: map[int]int{4: 1}},
+		},
+	}
+	opts := viewOptions{delimeter: " ", fields: []string{"Tag", "Category", "Entries", "Avg", "Ratings"}}
+
+	textRenderer{}.render(os.Stdout, tagsTables(topTags(tags, 1), true, opts), opts)
+
+	// Output:
+	// Oolong
+	// Tag     Category Entries  Avg Ratings
+	// roasted Roasted        3 3.00 4:1 3:1 2:1
+}
+
+func Example_sqlTable() {
+	columns := []string{"tea", "entries", "avg"}
+	rows := [][]interface{}{{"Dong Ding", int64(12), 3.25}, {"Bai Mu Dan", nil, 4.0}}
+	opts := viewOptions{delimeter: " "}
+
+	textRenderer{}.render(os.Stdout, []table{sqlTable(columns, rows, opts)}, opts)
+
+	opts.fields = []string{"tea", "avg"}
+	textRenderer{}.render(os.Stdout, []table{sqlTable(columns, rows, opts)}, opts)
+
+	// Output:
+	// tea        entries avg
+	// Dong Ding  12      3.25
+	// Bai Mu Dan         4
+	// tea        avg
+	// Dong Ding  3.25
+	// Bai Mu Dan 4
+}
+
+func Example_databasePath() {
+	opts := newOptions()
+	opts.DbCfg.DbType = "sqlite"
+	fmt.Println(databasePath(opts, "/home/tea"))
+	opts.DbCfg.DbType = "file"
+	fmt.Println(databasePath(opts, "/home/tea"))
+	opts.DbCfg.Path = "~/teas/journal.json"
+	fmt.Println(databasePath(opts, "/home/tea"))
+
+	// Output:
+	// /home/tea/.hgteas.db
+	// /home/tea/.hgteas.db.json
+	// /home/tea/teas/journal.json
+}
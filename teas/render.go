@@ -0,0 +1,294 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// table holds the values of a view, one row per record and one column per field, along with the printf
+// format of each field
+type table struct {
+	title   string
+	fields  []string
+	formats map[string]string
+	headers map[string]string
+	ratings map[string]bool
+	rows    [][]interface{}
+}
+
+func newTable(title string, fields []string, formats map[string]string) table {
+	return table{title: title, fields: fields, formats: formats, headers: make(map[string]string), ratings: make(map[string]bool), rows: make([][]interface{}, 0)}
+}
+
+// header returns the header of a column, which defaults to the name of its field
+func (t table) header(col int) string {
+	if header, ok := t.headers[t.fields[col]]; ok {
+		return header
+	}
+	return t.fields[col]
+}
+
+// validate returns an error listing the fields of the table which have no format
+func (t table) validate() error {
+	return unknownFields(t.fields, func(field string) bool {
+		_, ok := t.formats[field]
+		return ok
+	})
+}
+
+// rate marks the fields whose values are ratings, which are colored on a terminal
+func (t *table) rate(fields ...string) {
+	for _, field := range fields {
+		t.ratings[field] = true
+	}
+}
+
+// addRow adds a row with the value of each field of the table. A nil value is displayed as an empty cell.
+func (t *table) addRow(value func(field string) interface{}) {
+	row := make([]interface{}, len(t.fields))
+	for i, field := range t.fields {
+		row[i] = value(field)
+	}
+	t.rows = append(t.rows, row)
+}
+
+var re_verb = regexp.MustCompile("(\\.[0-9]+)?[a-z]+")
+var re_width = regexp.MustCompile("%-?[0-9]*")
+var re_rightPadded = regexp.MustCompile("^%[0-9]+")
+
+// cell formats the value of a field, padded to the width of the field unless unpadded is set
+func (t table) cell(row, col int, unpadded bool) string {
+	format, ok := t.formats[t.fields[col]]
+	if !ok {
+		return ""
+	}
+	if unpadded {
+		format = re_width.ReplaceAllString(format, "%")
+	}
+
+	value := t.rows[row][col]
+	if value == nil {
+		return fmt.Sprintf(re_verb.ReplaceAllString(format, "s"), "")
+	}
+	return fmt.Sprintf(format, value)
+}
+
+// numeric returns true if the field is displayed as a number
+func (t table) numeric(col int) bool {
+	format := t.formats[t.fields[col]]
+	return strings.ContainsAny(format, "df") && !strings.HasPrefix(format, "%-")
+}
+
+// rightAligned returns true if the field is a number or is padded on the left
+func (t table) rightAligned(col int) bool {
+	return t.numeric(col) || re_rightPadded.MatchString(t.formats[t.fields[col]])
+}
+
+type renderer interface {
+	render(w io.Writer, tables []table, opts viewOptions) error
+}
+
+var renderers = map[string]renderer{
+	"text":     textRenderer{},
+	"csv":      csvRenderer{},
+	"markdown": markdownRenderer{},
+	"html":     htmlRenderer{},
+}
+
+func render(opts viewOptions, tables ...table) {
+	r, ok := renderers[opts.format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unrecognized output format: %s\n", opts.format)
+		os.Exit(1)
+	}
+	for _, t := range tables {
+		if err := t.validate(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	}
+	if err := r.render(os.Stdout, tables, opts); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// textRenderer prints columns sized to their content and fit to the width of the terminal, or just the delimited
+// values when porcelain
+type textRenderer struct{}
+
+func (textRenderer) render(w io.Writer, tables []table, opts viewOptions) error {
+	for i, t := range tables {
+		if i != 0 {
+			fmt.Fprintln(w)
+		}
+		if t.title != "" && !opts.porcelain {
+			fmt.Fprintln(w, t.title)
+		}
+
+		if !opts.porcelain {
+			writeAligned(w, t, opts)
+			continue
+		}
+
+		headerOpts := opts
+		headerOpts.fields = t.fields
+		writeHeader(w, t.formats, headerOpts)
+
+		for row := range t.rows {
+			cells := make([]string, len(t.fields))
+			for col := range t.fields {
+				cells[col] = t.cell(row, col, false)
+			}
+			fmt.Fprintln(w, strings.Join(cells, opts.delimeter))
+		}
+	}
+	return nil
+}
+
+// csvRenderer prints RFC 4180 records, with multiple tables separated by an empty line and their titles
+type csvRenderer struct{}
+
+func (csvRenderer) render(w io.Writer, tables []table, opts viewOptions) error {
+	writer := csv.NewWriter(w)
+	for i, t := range tables {
+		if i != 0 {
+			writer.Flush()
+			fmt.Fprintln(w)
+		}
+		if t.title != "" && len(tables) > 1 {
+			writer.Write([]string{t.title})
+		}
+
+		header := make([]string, len(t.fields))
+		for col := range t.fields {
+			header[col] = t.header(col)
+		}
+		writer.Write(header)
+		for row := range t.rows {
+			record := make([]string, len(t.fields))
+			for col := range t.fields {
+				record[col] = t.cell(row, col, true)
+			}
+			writer.Write(record)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// markdownRenderer prints GitHub flavored markdown tables
+type markdownRenderer struct{}
+
+func markdownEscape(s string) string {
+	return strings.NewReplacer("|", "\\|", "\r\n", "<br>", "\n", "<br>").Replace(s)
+}
+
+func (markdownRenderer) render(w io.Writer, tables []table, opts viewOptions) error {
+	for i, t := range tables {
+		if i != 0 {
+			fmt.Fprintln(w)
+		}
+		if t.title != "" {
+			fmt.Fprintf(w, "### %s\n\n", markdownEscape(t.title))
+		}
+
+		header := make([]string, len(t.fields))
+		align := make([]string, len(t.fields))
+		for col := range t.fields {
+			header[col] = markdownEscape(t.header(col))
+			if t.numeric(col) {
+				align[col] = "---:"
+			} else {
+				align[col] = "---"
+			}
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(header, " | "))
+		fmt.Fprintf(w, "| %s |\n", strings.Join(align, " | "))
+
+		for row := range t.rows {
+			cells := make([]string, len(t.fields))
+			for col := range t.fields {
+				cells[col] = markdownEscape(t.cell(row, col, true))
+			}
+			fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+		}
+	}
+	return nil
+}
+
+// htmlRenderer prints a standalone page with a table for each table, which can be sorted by clicking the headers
+type htmlRenderer struct{}
+
+const htmlSortScript = `document.querySelectorAll("table").forEach(function(table) {
+  table.querySelectorAll("th").forEach(function(th, col) {
+    th.addEventListener("click", function() {
+      var body = table.tBodies[0];
+      var rows = Array.from(body.rows);
+      var ascending = th.dataset.order !== "asc";
+      rows.sort(function(a, b) {
+        var x = a.cells[col].textContent.trim(), y = b.cells[col].textContent.trim();
+        var nx = parseFloat(x), ny = parseFloat(y);
+        var c = (!isNaN(nx) && !isNaN(ny)) ? nx - ny : x.localeCompare(y);
+        return ascending ? c : -c;
+      });
+      rows.forEach(function(row) { body.appendChild(row); });
+      th.dataset.order = ascending ? "asc" : "desc";
+    });
+  });
+});`
+
+const htmlStyle = `body { font-family: sans-serif; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 0.25em 0.5em; }
+th { background: #eee; cursor: pointer; }
+td.numeric { text-align: right; }`
+
+func (htmlRenderer) render(w io.Writer, tables []table, opts viewOptions) error {
+	fmt.Fprintln(w, "<!DOCTYPE html>")
+	fmt.Fprintln(w, "<html>")
+	fmt.Fprintln(w, "<head>")
+	fmt.Fprintln(w, `<meta charset="utf-8">`)
+	fmt.Fprintln(w, "<title>teas</title>")
+	fmt.Fprintf(w, "<style>\n%s\n</style>\n", htmlStyle)
+	fmt.Fprintln(w, "</head>")
+	fmt.Fprintln(w, "<body>")
+
+	for _, t := range tables {
+		if t.title != "" {
+			fmt.Fprintf(w, "<h2>%s</h2>\n", html.EscapeString(t.title))
+		}
+		fmt.Fprintln(w, "<table>")
+		fmt.Fprint(w, "<thead><tr>")
+		for col := range t.fields {
+			fmt.Fprintf(w, "<th>%s</th>", html.EscapeString(t.header(col)))
+		}
+		fmt.Fprintln(w, "</tr></thead>")
+
+		fmt.Fprintln(w, "<tbody>")
+		for row := range t.rows {
+			fmt.Fprint(w, "<tr>")
+			for col := range t.fields {
+				if t.numeric(col) {
+					fmt.Fprint(w, `<td class="numeric">`)
+				} else {
+					fmt.Fprint(w, "<td>")
+				}
+				fmt.Fprintf(w, "%s</td>", html.EscapeString(t.cell(row, col, true)))
+			}
+			fmt.Fprintln(w, "</tr>")
+		}
+		fmt.Fprintln(w, "</tbody>")
+		fmt.Fprintln(w, "</table>")
+	}
+
+	fmt.Fprintf(w, "<script>\n%s\n</script>\n", htmlSortScript)
+	fmt.Fprintln(w, "</body>")
+	fmt.Fprintln(w, "</html>")
+	return nil
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+)
+
+func newTestTable() table {
+	t := newTable("Test", []string{"Name", "Count", "Avg"}, map[string]string{
+		"Name":  "%-10s",
+		"Count": "%3d",
+		"Avg":   "%6.2f",
+	})
+	for _, row := range [][]interface{}{{"Plain", 1, 2.5}, {"Tab\tand, comma", nil, 10.0}, {"Pipe | \"quote\"", 3, 1.0}} {
+		t.addRow(func(field string) interface{} {
+			return map[string]interface{}{"Name": row[0], "Count": row[1], "Avg": row[2]}[field]
+		})
+	}
+	return t
+}
+
+func Example_textRenderer() {
+	textRenderer{}.render(os.Stdout, []table{newTestTable()}, viewOptions{delimeter: "|"})
+
+	// Output:
+	// Test
+	// Name          |Count|  Avg
+	// Plain         |    1| 2.50
+	// Tab and, comma|     |10.00
+	// Pipe | "quote"|    3| 1.00
+}
+
+func Example_textRendererPorcelain() {
+	textRenderer{}.render(os.Stdout, []table{newTestTable()}, viewOptions{delimeter: "|", porcelain: true})
+
+	// Output:
+	// Plain|1|2.50
+	// Tab	and, comma||10.00
+	// Pipe | "quote"|3|1.00
+}
+
+func Example_csvRenderer() {
+	csvRenderer{}.render(os.Stdout, []table{newTestTable()}, viewOptions{})
+
+	// Output:
+	// Name,Count,Avg
+	// Plain,1,2.50
+	// "Tab	and, comma",,10.00
+	// "Pipe | ""quote""",3,1.00
+}
+
+func Example_markdownRenderer() {
+	markdownRenderer{}.render(os.Stdout, []table{newTestTable()}, viewOptions{})
+
+	// Output:
+	// ### Test
+	//
+	// | Name | Count | Avg |
+	// | --- | ---: | ---: |
+	// | Plain | 1 | 2.50 |
+	// | Tab	and, comma |  | 10.00 |
+	// | Pipe \| "quote" | 3 | 1.00 |
+}
+
+func TestHtmlRenderer(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (htmlRenderer{}).render(&buf, []table{newTestTable()}, viewOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	page := buf.String()
+	for _, expected := range []string{"<!DOCTYPE html>", "<h2>Test</h2>", "<th>Count</th>", `<td class="numeric">10.00</td>`, "Pipe | &#34;quote&#34;", "<script>"} {
+		if !strings.Contains(page, expected) {
+			t.Errorf("Did not find %s in the page", expected)
+		}
+	}
+}
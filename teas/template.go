@@ -0,0 +1,326 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"gitlab.com/hokiegeek/hgtealib"
+	"io"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// teaView is what a template is executed against for each tea. All of the fields and methods of hgtealib.Tea are
+// available, i.e.: {{.Id}}, {{.Name}}, {{.Type}}, {{.Origin}}, {{.Picked.Year}}, {{.Picked.Flush}}, {{.Size}},
+// {{.LeafGrade}}, {{.Purchased.Price}}, {{.Ratings}}, along with the computed {{.Stats}}
+type teaView struct {
+	hgtealib.Tea
+	Stats teaStatsView
+}
+
+// teaStatsView holds the statistics of the entries of a tea
+type teaStatsView struct {
+	Entries        int
+	Sessions       int
+	Mean           float64
+	Median         int
+	Mode           int
+	CostPerSession float64
+}
+
+func newTeaView(tea hgtealib.Tea) *teaView {
+	v := &teaView{Tea: tea}
+	v.Stats = teaStatsView{
+		Entries:        tea.LogLen(),
+		Sessions:       tea.Sessions(),
+		Median:         tea.Median(),
+		Mode:           tea.Mode(),
+		CostPerSession: tea.CostPerSession(),
+	}
+	for _, entry := range tea.Log() {
+		v.Stats.Mean += float64(entry.Rating)
+	}
+	if v.Stats.Entries > 0 {
+		v.Stats.Mean /= float64(v.Stats.Entries)
+	}
+	return v
+}
+
+func newTeaViews(teas []hgtealib.Tea) []*teaView {
+	views := make([]*teaView, 0)
+	for _, tea := range teas {
+		views = append(views, newTeaView(tea))
+	}
+	return views
+}
+
+// entryView is what a template is executed against for each journal entry. All of the fields and methods of
+// hgtealib.Entry are available, i.e.: {{.DateTime}}, {{.Rating}}, {{.Comments}}, {{.SteepTime}},
+// {{.SteepingTemperature}}, {{.VesselName}}, {{.Fixins}}, {{.Ratio}}, along with the {{.TeaName}}
+type entryView struct {
+	hgtealib.Entry
+	TeaName string
+}
+
+func newEntryViews(db *hgtealib.TeaDb, log []hgtealib.Entry) []*entryView {
+	views := make([]*entryView, 0)
+	for _, entry := range log {
+		v := &entryView{Entry: entry}
+		if tea, err := db.Tea(entry.Tea); err == nil {
+			v.TeaName = tea.String()
+		}
+		views = append(views, v)
+	}
+	return views
+}
+
+// statsView is what a template is executed against for each group of the stats, i.e.: {{.Group}}, {{.Teas}},
+// {{.Entries}}, {{.Average}}, {{.Median}}, {{.Mode}}, {{.Ratio}}, {{.Ratings}}
+type statsView struct {
+	Group string
+	hgtealib.Stats
+}
+
+func newStatsViews(stats map[string]hgtealib.Stats) []*statsView {
+	views := make([]*statsView, 0)
+	for _, g := range sortedKeys(stats) {
+		views = append(views, &statsView{Group: g, Stats: stats[g]})
+	}
+	return views
+}
+
+// spendView is what a template is executed against for each group of the spend, i.e.: {{.By}}, {{.Group}},
+// {{.Teas}}, {{.Totals}}
+type spendView struct {
+	By    string
+	Group string
+	hgtealib.Spend
+}
+
+func newSpendViews(by string, spend map[string]hgtealib.Spend) []*spendView {
+	views := make([]*spendView, 0)
+	for _, g := range sortedKeys(spend) {
+		views = append(views, &spendView{By: by, Group: g, Spend: spend[g]})
+	}
+	return views
+}
+
+// inventoryView is what a template is executed against for each tea of the inventory, i.e.: {{.Tea.Name}},
+// {{.Quantity}}, {{.Used}}, {{.Remaining}}, {{.DailyRate}}, {{.DaysLeft}}, {{.Finished}}
+type inventoryView struct {
+	Tea *teaView
+	hgtealib.TeaInventory
+}
+
+func newInventoryViews(teas []hgtealib.Tea, inventory map[int]hgtealib.TeaInventory) []*inventoryView {
+	views := make([]*inventoryView, 0)
+	for _, tea := range teas {
+		if i, ok := inventory[tea.Id]; ok && i.Quantity > 0 {
+			views = append(views, &inventoryView{Tea: newTeaView(tea), TeaInventory: i})
+		}
+	}
+	return views
+}
+
+// agingView is what a template is executed against for each aging tea, i.e.: {{.Tea.Name}}, {{.Age}},
+// {{.Target}}, {{.ReachedTarget}}, {{.RatingsByAge}}
+type agingView struct {
+	Tea           *teaView
+	Age           float64
+	Target        float64
+	ReachedTarget bool
+	RatingsByAge  []hgtealib.AgeRating
+}
+
+func newAgingViews(teas []hgtealib.Tea, now time.Time) []*agingView {
+	views := make([]*agingView, 0)
+	for _, tea := range teas {
+		age, ok := tea.AgeAt(now)
+		if !ok {
+			continue
+		}
+		views = append(views, &agingView{
+			Tea:           newTeaView(tea),
+			Age:           age,
+			Target:        tea.AgingTarget(),
+			ReachedTarget: tea.ReachedAgingTarget(now),
+			RatingsByAge:  tea.RatingsByAge(),
+		})
+	}
+	return views
+}
+
+// vesselView is what a template is executed against for each vessel, i.e.: {{.Vessel.Name}}, {{.Vessel.Type}},
+// {{.Vessel.Material}}, {{.Vessel.Volume}}, {{.Entries}}, {{.Average}}, {{.Types}}, {{.Misuses}}
+type vesselView struct {
+	hgtealib.VesselUse
+}
+
+func newVesselViews(use map[string]hgtealib.VesselUse) []*vesselView {
+	views := make([]*vesselView, 0)
+	for _, name := range sortedKeys(use) {
+		views = append(views, &vesselView{VesselUse: use[name]})
+	}
+	return views
+}
+
+// teaDetailView is what a template is executed against for the shown tea. Along with everything in teaView, the
+// {{.Consumed}} sessions, the {{.RatingCounts}}, the steeping {{.Recommendation}} and the most recent journal
+// {{.Entries}} are available.
+type teaDetailView struct {
+	*teaView
+	RatingCounts   map[int]int
+	Consumed       float64
+	Recommendation *hgtealib.SteepingRecommendation
+	Entries        []*entryView
+}
+
+func newTeaDetailView(db *hgtealib.TeaDb, tea hgtealib.Tea, last int) *teaDetailView {
+	v := &teaDetailView{
+		teaView:      newTeaView(tea),
+		RatingCounts: tea.RatingCounts(),
+		Consumed:     db.Consumption()[tea.Id],
+		Entries:      newEntryViews(db, lastEntries(tea, last)),
+	}
+	if r, ok := tea.Recommendation(); ok {
+		v.Recommendation = &r
+	}
+	return v
+}
+
+// tagView is what a template is executed against for each tag, i.e.: {{.Group}}, {{.Name}}, {{.Category}},
+// {{.Entries}}, {{.Teas}}, {{.Average}}, {{.Ratings}}
+type tagView struct {
+	Group string
+	hgtealib.TagStats
+}
+
+func newTagViews(tags map[string][]hgtealib.TagStats) []*tagView {
+	views := make([]*tagView, 0)
+	for _, g := range sortedKeys(tags) {
+		for _, s := range tags[g] {
+			views = append(views, &tagView{Group: g, TagStats: s})
+		}
+	}
+	return views
+}
+
+// searchView is what a template is executed against for each search hit, i.e.: {{.Tea.Name}}, {{.Entry.Rating}},
+// {{.Field}}, {{.Score}}, {{.Snippet}}, or the snippet with its matches marked, i.e.: {{.Mark "**" "**"}}. The
+// Entry is nil when the hit is on the tea itself.
+type searchView struct {
+	Tea        *teaView
+	Entry      *entryView
+	Field      string
+	Score      float64
+	Snippet    string
+	Highlights [][2]int
+}
+
+func (v searchView) Mark(open, close string) string {
+	return highlighted{v.Snippet, v.Highlights}.mark(v.Snippet, open, close)
+}
+
+func newSearchViews(db *hgtealib.TeaDb, hits []hgtealib.SearchHit) []*searchView {
+	views := make([]*searchView, 0)
+	for _, hit := range hits {
+		v := &searchView{Field: hit.Field, Score: hit.Score, Snippet: hit.Snippet, Highlights: hit.Highlights}
+		if tea, err := db.Tea(hit.Tea); err == nil {
+			v.Tea = newTeaView(tea)
+		}
+		if hit.Entry != nil {
+			v.Entry = newEntryViews(db, []hgtealib.Entry{*hit.Entry})[0]
+		}
+		views = append(views, v)
+	}
+	return views
+}
+
+var templateFuncs = template.FuncMap{
+	// duration formats a duration rounded to the second, i.e.: 3m5s
+	"duration": func(d time.Duration) string {
+		return d.Round(time.Second).String()
+	},
+	// minutes returns a duration as a number of minutes
+	"minutes": func(d time.Duration) float64 {
+		return d.Minutes()
+	},
+	// fixins joins the names of fixins with commas
+	"fixins": func(fixins []hgtealib.TeaFixin) string {
+		names := make([]string, len(fixins))
+		for i, f := range fixins {
+			names[i] = f.String()
+		}
+		return strings.Join(names, ", ")
+	},
+	// date formats a time with the given layout, which defaults to 2006-01-02. Zero times are empty.
+	"date": func(t time.Time, layout ...string) string {
+		if t.IsZero() {
+			return ""
+		}
+		if len(layout) > 0 {
+			return t.Format(layout[0])
+		}
+		return t.Format("2006-01-02")
+	},
+	// price formats an amount in the given currency, i.e.: $12.50
+	"price": hgtealib.FormatPrice,
+	// join joins strings with the separator
+	"join": func(sep string, s []string) string {
+		return strings.Join(s, sep)
+	},
+}
+
+func parseTemplate(text, file string) (*template.Template, error) {
+	switch {
+	case text != "" && file != "":
+		return nil, errors.New("Only one of a template or a template file can be given")
+	case file != "":
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	case text == "":
+		return nil, nil
+	}
+
+	return template.New("teas").Funcs(templateFuncs).Parse(text)
+}
+
+// writeTemplate executes the template for each element of the views, or once if it is not a slice, ending each
+// execution with a newline unless the template already does
+func writeTemplate(w io.Writer, tmpl *template.Template, views interface{}) error {
+	records := make([]interface{}, 0)
+	if value := reflect.ValueOf(views); value.Kind() == reflect.Slice {
+		for i := 0; i < value.Len(); i++ {
+			records = append(records, value.Index(i).Interface())
+		}
+	} else {
+		records = append(records, views)
+	}
+
+	for _, r := range records {
+		var buf strings.Builder
+		if err := tmpl.Execute(&buf, r); err != nil {
+			return err
+		}
+		if !strings.HasSuffix(buf.String(), "\n") {
+			buf.WriteString("\n")
+		}
+		if _, err := io.WriteString(w, buf.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printTemplate(views interface{}, opts viewOptions) {
+	if err := writeTemplate(os.Stdout, opts.template, views); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"gitlab.com/hokiegeek/hgtealib"
+	"os"
+	"testing"
+	"time"
+)
+
+func Example_writeTemplate() {
+	tea := hgtealib.Tea{Id: 42, Name: "Test Tea", Origin: hgtealib.TeaOrigin{Country: "India", Region: "Assam"}}
+	tea.Add(hgtealib.Entry{Tea: 42, DateTime: time.Date(2016, 1, 2, 8, 0, 0, 0, time.UTC), Rating: 3})
+	tea.Add(hgtealib.Entry{Tea: 42, DateTime: time.Date(2016, 1, 3, 8, 0, 0, 0, time.UTC), Rating: 4})
+
+	tmpl, _ := parseTemplate(`{{.Name}} ({{.Origin}}) — {{printf "%.1f" .Stats.Mean}}`, "")
+	writeTemplate(os.Stdout, tmpl, newTeaViews([]hgtealib.Tea{tea}))
+
+	// Output: Test Tea (Assam, India) — 3.5
+}
+
+func Example_templateFuncs() {
+	entry := &entryView{
+		Entry: hgtealib.Entry{
+			DateTime:  time.Date(2016, 1, 2, 8, 0, 0, 0, time.UTC),
+			SteepTime: 3*time.Minute + 5*time.Second + 300*time.Millisecond,
+			Fixins:    []hgtealib.TeaFixin{hgtealib.Milk, hgtealib.Sugar},
+		},
+		TeaName: "Test Tea",
+	}
+
+	tmpl, _ := parseTemplate(`{{date .DateTime}} {{date .DateTime "Jan 2"}} {{.TeaName}} {{duration .SteepTime}} {{printf "%.0f" (minutes .SteepTime)}}m [{{fixins .Fixins}}] {{price 12.5 "USD"}}`, "")
+	writeTemplate(os.Stdout, tmpl, entry)
+
+	// Output: 2016-01-02 Jan 2 Test Tea 3m5s 3m [Milk, Sugar] $12.50
+}
+
+func TestParseTemplate(t *testing.T) {
+	if tmpl, err := parseTemplate("", ""); tmpl != nil || err != nil {
+		t.Error("Unexpectedly parsed a template when none was given")
+	}
+
+	if _, err := parseTemplate("{{.Name}}", "/dev/null"); err == nil {
+		t.Error("Successfully parsed both a template and a template file")
+	}
+
+	if _, err := parseTemplate("{{.Name", ""); err == nil {
+		t.Error("Successfully parsed a malformed template")
+	}
+
+	if _, err := parseTemplate("", "/does/not/exist"); err == nil {
+		t.Error("Successfully parsed a template file that does not exist")
+	}
+}
@@ -3,14 +3,39 @@ package hgtealib
 import (
 	"encoding/csv"
 	"errors"
+	"fmt"
 	"golang.org/x/net/proxy"
+	"io"
 	"net/http"
+	"os"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 )
 
+func readTsv(r io.Reader) ([][]string, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = '\t'
+	return reader.ReadAll()
+}
+
+// getSheetTsv retrieves a published sheet, or reads a local file for a file:// URL, such as a sheet that was
+// exported with WriteTsv
 func getSheetTsv(url, proxyAddr string) ([][]string, error) {
+	switch {
+	case strings.HasPrefix(url, "file://"):
+		file, err := os.Open(strings.TrimPrefix(url, "file://"))
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return readTsv(file)
+	case !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://"):
+		return nil, errors.New(fmt.Sprintf("Unsupported sheet URL '%s', expected http://, https:// or file://", url))
+	}
+
 	var response *http.Response
 	var err error
 	if proxyAddr != "" {
@@ -39,20 +64,26 @@ func getSheetTsv(url, proxyAddr string) ([][]string, error) {
 
 	defer response.Body.Close()
 
-	r := csv.NewReader(response.Body)
-	r.Comma = '\t'
-	db, err := r.ReadAll()
-	if err != nil {
-		return nil, err
-	}
+	return readTsv(response.Body)
+}
 
-	return db, nil
+// TsvWarning reports a row of the journal sheet which was loaded without some of its data, or was left out
+type TsvWarning struct {
+	Row     int
+	Message string
 }
 
-func newEntryFromTsv(entry []string) (*Entry, error) {
-	if len(entry) < 11 {
-		return nil, errors.New("Data badly formatted")
+func (w TsvWarning) Error() string {
+	return fmt.Sprintf("Journal row %d: %s", w.Row, w.Message)
+}
+
+// newEntryFromTsv creates the entry of a journal row. An unknown vessel is loaded as Other and unknown fixins are
+// left out, which is reported in the returned warnings so that the rest of the journal still loads.
+func newEntryFromTsv(entry []string) (*Entry, []string, error) {
+	if len(entry) < 12 {
+		return nil, nil, errors.New("Data badly formatted")
 	}
+	var warnings []string
 
 	e := new(Entry)
 
@@ -61,10 +92,14 @@ func newEntryFromTsv(entry []string) (*Entry, error) {
 
 	e.Rating, _ = strconv.Atoi(entry[4])
 	e.Comments = entry[5]
+	e.ParseTags()
 
 	e.ParseSteepTime(entry[7])
-	dummy_int, _ := strconv.Atoi(entry[8])
-	e.SteepingVessel = VesselType(dummy_int)
+	if err := e.ParseVessel(entry[8]); err != nil {
+		warnings = append(warnings, fmt.Sprintf("%s, loaded as %s", err, Other))
+		e.Vessel = ""
+		e.SteepingVessel = Other
+	}
 	e.SteepingTemperature, _ = strconv.Atoi(entry[9])
 	if e.SteepingTemperature == 0 {
 		// TODO: make this value depend on the type (if green or oolong, for example)
@@ -74,12 +109,103 @@ func newEntryFromTsv(entry []string) (*Entry, error) {
 	e.SessionInstance = entry[10]
 	for _, f := range strings.Split(entry[11], ";") {
 		if f != "" {
-			dummy, _ := strconv.Atoi(f)
-			e.Fixins = append(e.Fixins, TeaFixin(dummy))
+			fixin, err := ParseTeaFixin(f)
+			if err != nil {
+				warnings = append(warnings, fmt.Sprintf("%s, left out", err))
+				continue
+			}
+			e.Fixins = append(e.Fixins, fixin)
 		}
 	}
 
-	return e, nil
+	// The leaf and water columns are optional
+	var err error
+	if len(entry) > 12 && entry[12] != "" {
+		if e.LeafGrams, err = strconv.ParseFloat(entry[12], 64); err != nil {
+			return nil, nil, err
+		}
+	}
+	if len(entry) > 13 && entry[13] != "" {
+		if e.WaterMl, err = strconv.Atoi(entry[13]); err != nil {
+			return nil, nil, err
+		}
+	}
+	// An export also writes the full time, which keeps apart the entries of the same minute
+	if len(entry) > 14 && entry[14] != "" {
+		if err := e.ParseTimestamp(entry[14]); err != nil {
+			warnings = append(warnings, fmt.Sprintf("%s, the time was kept to the minute", err))
+		}
+	}
+
+	return e, warnings, nil
+}
+
+func parseTeaBlend(teas, ratios string) (TeaBlend, error) {
+	if teas == "" {
+		return nil, nil
+	}
+
+	ids := strings.Split(teas, ";")
+	var parts []string
+	if ratios != "" {
+		parts = regexp.MustCompile("[;:]").Split(ratios, -1)
+		if len(parts) != len(ids) {
+			return nil, errors.New(fmt.Sprintf("Blend ratio '%s' does not match the blended teas '%s'", ratios, teas))
+		}
+	}
+
+	blend := make(TeaBlend, len(ids))
+	for i, id := range ids {
+		var err error
+		if blend[i].Tea, err = strconv.Atoi(strings.TrimSpace(id)); err != nil {
+			return nil, err
+		}
+
+		if parts != nil {
+			if blend[i].Ratio, err = strconv.ParseFloat(strings.TrimSpace(parts[i]), 64); err != nil {
+				return nil, err
+			}
+		} else {
+			blend[i].Ratio = 1
+		}
+	}
+
+	return blend, nil
+}
+
+func parseProductRatings(data string) (map[string]int, error) {
+	if data == "" {
+		return nil, nil
+	}
+
+	ratings := make(map[string]int)
+	for i, r := range strings.Split(data, ";") {
+		name := ""
+		value := r
+		if kv := strings.SplitN(r, ":", 2); len(kv) == 2 {
+			var ok bool
+			if name, ok = productRatingName(kv[0]); !ok {
+				return nil, errors.New(fmt.Sprintf("Unknown product rating: %s", kv[0]))
+			}
+			value = kv[1]
+		} else if i < len(TeaProductRatings) {
+			name = TeaProductRatings[i]
+		} else {
+			return nil, errors.New(fmt.Sprintf("Too many product ratings: %s", data))
+		}
+
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+
+		var err error
+		if ratings[name], err = strconv.Atoi(value); err != nil {
+			return nil, err
+		}
+	}
+
+	return ratings, nil
 }
 
 func newTeaFromTsv(data []string) (*Tea, error) {
@@ -98,8 +224,9 @@ func newTeaFromTsv(data []string) (*Tea, error) {
 	}
 	t.Name = data[3]
 	t.Type = data[4]
-	t.Size = data[18]
-	t.LeafGrade = data[15]
+	t.Comments = data[12]
+	t.ParseSize(data[18])
+	t.LeafGrade = ParseLeafGrade(data[15])
 
 	t.Origin.Country = data[14]
 	t.Origin.Region = data[5]
@@ -113,19 +240,18 @@ func newTeaFromTsv(data []string) (*Tea, error) {
 		}
 	}
 	if data[7] != "" {
-		dummy_float, err := strconv.ParseFloat(data[7], 64)
+		flush, err := ParseFlush(data[7])
 		if err != nil {
 			return nil, err
 		}
-		t.Picked.Flush = Flush(dummy_float)
+		t.Picked.Flush = TeaFlush{Flush: flush, Naming: t.Origin.FlushNaming()}
 	}
 
 	t.Purchased.Location = data[8]
-	t.Purchased.Date = data[9]
-	if data[10] != "" {
-		if t.Purchased.Price, err = strconv.ParseFloat(data[10], 64); err != nil {
-			return nil, err
-		}
+	// A purchase date which is not recognized is kept as it was written
+	t.Purchased.ParseDate(data[9])
+	if err = t.Purchased.ParsePrice(data[10]); err != nil {
+		return nil, err
 	}
 	if data[21] != "" {
 		dummy_int, err := strconv.Atoi(data[21])
@@ -137,9 +263,96 @@ func newTeaFromTsv(data []string) (*Tea, error) {
 		t.Purchased.Packaging = Unknown
 	}
 
+	if t.Ratings, err = parseProductRatings(data[11]); err != nil {
+		return nil, err
+	}
+
+	if t.Blend, err = parseTeaBlend(data[16], data[17]); err != nil {
+		return nil, err
+	}
+
+	// The leaf per session column is optional
+	if len(data) > 22 && data[22] != "" {
+		if t.LeafPerSession, err = strconv.ParseFloat(data[22], 64); err != nil {
+			return nil, err
+		}
+	}
+
 	return t, nil
 }
 
+// LoadVesselTypesTsv adds the vessel types from a lookup sheet with the columns: ID, Name, Volume
+func LoadVesselTypesTsv(url, proxyAddr string) error {
+	data, err := getSheetTsv(url, proxyAddr)
+	if err != nil {
+		return err
+	}
+
+	for i, row := range data {
+		if i == 0 {
+			continue
+		}
+		if len(row) < 2 {
+			return errors.New("Vessel data badly formatted")
+		}
+
+		var def VesselTypeDefinition
+		dummy_int, err := strconv.Atoi(row[0])
+		if err != nil {
+			return err
+		}
+		def.Id = VesselType(dummy_int)
+		def.Name = row[1]
+		if len(row) > 2 && row[2] != "" {
+			if def.Volume, err = strconv.Atoi(row[2]); err != nil {
+				return err
+			}
+		}
+
+		if err := AddVesselType(def); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LoadTeaFixinsTsv adds the fixins from a lookup sheet with the columns: ID, Name, Category
+func LoadTeaFixinsTsv(url, proxyAddr string) error {
+	data, err := getSheetTsv(url, proxyAddr)
+	if err != nil {
+		return err
+	}
+
+	for i, row := range data {
+		if i == 0 {
+			continue
+		}
+		if len(row) < 2 {
+			return errors.New("Fixin data badly formatted")
+		}
+
+		var def FixinDefinition
+		dummy_int, err := strconv.Atoi(row[0])
+		if err != nil {
+			return err
+		}
+		def.Id = TeaFixin(dummy_int)
+		def.Name = row[1]
+		if len(row) > 2 {
+			if def.Category, err = ParseFixinCategory(row[2]); err != nil {
+				return err
+			}
+		}
+
+		if err := AddTeaFixin(def); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func NewFromTsv(teas_url, log_url, proxyAddr string) (*TeaDb, error) {
 	// Get the tea database
 	teasTsv, err := getSheetTsv(teas_url, proxyAddr)
@@ -147,11 +360,23 @@ func NewFromTsv(teas_url, log_url, proxyAddr string) (*TeaDb, error) {
 		return nil, err
 	}
 
+	// Add the journal entries
+	journalTsv, err := getSheetTsv(log_url, proxyAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	return newTeaDbFromTsv(teasTsv, journalTsv)
+}
+
+// newTeaDbFromTsv creates a read-only database from the rows of the teas and journal sheets, including their headers
+func newTeaDbFromTsv(teasTsv, journalTsv [][]string) (*TeaDb, error) {
 	if len(teasTsv) <= 0 {
 		return nil, errors.New("Did not retrieve any teas from the given URL")
 	}
 
 	teas := make([]*Tea, 0)
+	byId := make(map[int]Tea)
 	for _, tea := range teasTsv[1:] {
 		t, err := newTeaFromTsv(tea)
 		if err != nil {
@@ -159,12 +384,7 @@ func NewFromTsv(teas_url, log_url, proxyAddr string) (*TeaDb, error) {
 		}
 
 		teas = append(teas, t)
-	}
-
-	// Add the journal entries
-	journalTsv, err := getSheetTsv(log_url, proxyAddr)
-	if err != nil {
-		return nil, err
+		byId[t.Id] = *t
 	}
 
 	if len(journalTsv) <= 0 {
@@ -172,14 +392,186 @@ func NewFromTsv(teas_url, log_url, proxyAddr string) (*TeaDb, error) {
 	}
 
 	entries := make([]*Entry, 0)
-	for _, entry := range journalTsv[1:] {
-		e, err := newEntryFromTsv(entry)
+	var warnings []error
+	for i, entry := range journalTsv[1:] {
+		e, messages, err := newEntryFromTsv(entry)
 		if err != nil {
 			return nil, err
 		}
+		for _, m := range messages {
+			warnings = append(warnings, TsvWarning{Row: i + 2, Message: m})
+		}
+
+		// The tea can be given by its exact name instead of its id, since a looser match could credit the entry to
+		// the wrong tea. A row whose name does not match exactly one tea is left out.
+		if _, err := strconv.Atoi(entry[3]); err != nil && entry[3] != "" {
+			candidates := exactTeas(byId, strings.TrimSpace(entry[3]))
+			if len(candidates) != 1 {
+				warnings = append(warnings, TsvWarning{Row: i + 2, Message: fmt.Sprintf("%d teas are named '%s', the entry was left out", len(candidates), entry[3])})
+				continue
+			}
+			e.Tea = candidates[0].Id
+		}
 
 		entries = append(entries, e)
 	}
 
-	return newTeaDb(teas, entries)
+	db, err := newTeaDb(teas, entries)
+	if err != nil {
+		return nil, err
+	}
+	db.store = readOnlyStore{"tsv"}
+	db.warnings = warnings
+	return db, nil
+}
+
+var tsvTeasHeader = []string{"Timestamp", "Date", "ID", "Name", "Type", "Region", "Year", "Flush", "Purchase Location", "Purchase Date", "Purchase Price", "Ratings", "Comments", "Pictures", "Country", "Leaf Grade", "Blended Teas", "Blend Ratio", "Size", "Stocked", "Aging", "Packaging", "Leaf Per Session"}
+var tsvJournalHeader = []string{"Timestamp", "Date", "Time", "Tea", "Rating", "Comments", "Pictures", "Steep Time", "Steeping Vessel", "Steep Temperature", "Session Instance", "Fixins", "Leaf Grams", "Water ml", "Full Time"}
+
+func formatTsvBool(b bool) string {
+	if b {
+		return "TRUE"
+	}
+	return "FALSE"
+}
+
+func formatTsvFloat(f float64) string {
+	if f == 0 {
+		return ""
+	}
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func formatProductRatings(ratings map[string]int) string {
+	values := make([]string, len(TeaProductRatings))
+	last := -1
+	for i, name := range TeaProductRatings {
+		if rating, ok := ratings[name]; ok {
+			values[i] = strconv.Itoa(rating)
+			last = i
+		}
+	}
+	return strings.Join(values[:last+1], ";")
+}
+
+// teaToTsv is the inverse of newTeaFromTsv
+func teaToTsv(t Tea) []string {
+	data := make([]string, len(tsvTeasHeader))
+
+	data[2] = strconv.Itoa(t.Id)
+	data[3] = t.Name
+	data[4] = t.Type
+	data[5] = t.Origin.Region
+	if t.Picked.Year != 0 {
+		data[6] = strconv.Itoa(t.Picked.Year)
+	}
+	data[7] = formatTsvFloat(float64(t.Picked.Flush.Flush))
+
+	data[8] = t.Purchased.Location
+	if !t.Purchased.Date.IsZero() {
+		data[9] = t.Purchased.Date.Format("1/2/2006")
+	} else {
+		data[9] = t.Purchased.DateText
+	}
+	if t.Purchased.Price != 0 || t.Purchased.Currency != "" {
+		currency := t.Purchased.Currency
+		if currency == "" {
+			currency = DefaultCurrency
+		}
+		data[10] = strconv.FormatFloat(t.Purchased.Price, 'f', -1, 64) + " " + currency
+	}
+
+	data[11] = formatProductRatings(t.Ratings)
+	data[12] = t.Comments
+	data[14] = t.Origin.Country
+	data[15] = t.LeafGrade.Raw
+
+	if len(t.Blend) > 0 {
+		ids := make([]string, len(t.Blend))
+		ratios := make([]string, len(t.Blend))
+		for i, c := range t.Blend {
+			ids[i] = strconv.Itoa(c.Tea)
+			ratios[i] = strconv.FormatFloat(c.Ratio, 'f', -1, 64)
+		}
+		data[16] = strings.Join(ids, ";")
+		data[17] = strings.Join(ratios, ";")
+	}
+
+	data[18] = t.Size
+	data[19] = formatTsvBool(t.Storage.Stocked)
+	data[20] = formatTsvBool(t.Storage.Aging)
+	data[21] = strconv.Itoa(int(t.Purchased.Packaging))
+	data[22] = formatTsvFloat(t.LeafPerSession)
+
+	return data
+}
+
+// entryToTsv is the inverse of newEntryFromTsv. The sheets keep the time of an entry to the minute.
+func entryToTsv(e Entry) []string {
+	data := make([]string, len(tsvJournalHeader))
+
+	at := e.DateTime.In(journalLocation())
+	data[0] = at.Format("1/2/2006 15:04:05")
+	data[1] = at.Format("1/2/2006")
+	data[2] = fmt.Sprintf("%d%02d", at.Hour(), at.Minute())
+
+	data[3] = strconv.Itoa(e.Tea)
+	data[4] = strconv.Itoa(e.Rating)
+	data[5] = e.Comments
+	data[7] = e.SteepTime.String()
+	if e.Vessel != "" {
+		data[8] = e.Vessel
+	} else {
+		data[8] = strconv.Itoa(int(e.SteepingVessel))
+	}
+	data[9] = strconv.Itoa(e.SteepingTemperature)
+	data[10] = e.SessionInstance
+
+	fixins := make([]string, len(e.Fixins))
+	for i, f := range e.Fixins {
+		fixins[i] = strconv.Itoa(int(f))
+	}
+	data[11] = strings.Join(fixins, ";")
+
+	data[12] = formatTsvFloat(e.LeafGrams)
+	if e.WaterMl != 0 {
+		data[13] = strconv.Itoa(e.WaterMl)
+	}
+	data[14] = at.Format(time.RFC3339Nano)
+
+	return data
+}
+
+func writeTsv(w io.Writer, rows [][]string) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = '\t'
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// WriteTsv writes the teas and journal in the layout of the sheets that NewFromTsv reads, including the headers, so
+// that they can be imported into the sheets or read back from the files. The teas are ordered by id and the
+// entries by time.
+func (d *TeaDb) WriteTsv(teas, journal io.Writer) error {
+	ids := make([]int, 0, len(d.teas))
+	for id := range d.teas {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	teasTsv := [][]string{tsvTeasHeader}
+	for _, id := range ids {
+		teasTsv = append(teasTsv, teaToTsv(d.teas[id]))
+	}
+	if err := writeTsv(teas, teasTsv); err != nil {
+		return err
+	}
+
+	journalTsv := [][]string{tsvJournalHeader}
+	for _, at := range d.logSortedKeys {
+		journalTsv = append(journalTsv, entryToTsv(d.log[at]))
+	}
+	return writeTsv(journal, journalTsv)
 }
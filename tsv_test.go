@@ -4,9 +4,13 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -25,13 +29,13 @@ var testTsvTeas = [][]string{
 		"Purchase Location",
 		"1/1/2000", // 9
 		"99.99",
-		"Ratings",
+		"Value:3;Brewed Aroma:4",
 		"Comments", // 12
 		"Pictures",
 		"Country",
 		"Leaf Grade", // 15
-		"Blended Teas",
-		"Blend Ratio",
+		"",
+		"",
 		"Size", // 18
 		"TRUE",
 		"FALSE",
@@ -135,7 +139,7 @@ func CreateTestTea() (*Tea, error) {
 	}
 
 	for _, entry := range testTsvEntries {
-		e, err := newEntryFromTsv(entry)
+		e, _, err := newEntryFromTsv(entry)
 		if err != nil {
 			return nil, err
 		}
@@ -232,8 +236,8 @@ func isTsvEqualToTea(expected []string, received *Tea) (bool, error) {
 		return false, errors.New(fmt.Sprintf("Year field '%s' did not match expected '%s'", received.Picked.Year, expected[6]))
 	}
 
-	dummy_float, _ := strconv.ParseFloat(expected[7], 64)
-	if Flush(dummy_float) != received.Picked.Flush {
+	dummy_flush, _ := ParseFlush(expected[7])
+	if dummy_flush != received.Picked.Flush.Flush {
 		return false, errors.New(fmt.Sprintf("Flush field '%s' did not match expected '%s'", received.Picked.Flush, expected[7]))
 	}
 
@@ -241,22 +245,25 @@ func isTsvEqualToTea(expected []string, received *Tea) (bool, error) {
 		return false, errors.New(fmt.Sprintf("PurchaseLocation field '%s' did not match expected '%s'", received.Purchased.Location, expected[8]))
 	}
 
-	if expected[9] != received.Purchased.Date {
+	var purchased TeaPurchaseInfo
+	purchased.ParseDate(expected[9])
+	if !purchased.Date.Equal(received.Purchased.Date) {
 		return false, errors.New(fmt.Sprintf("PurchaseDate field '%s' did not match expected '%s'", received.Purchased.Date, expected[9]))
 	}
 
-	dummy_float, _ = strconv.ParseFloat(expected[10], 64)
-	if dummy_float != received.Purchased.Price {
+	purchased.ParsePrice(expected[10])
+	if purchased.Price != received.Purchased.Price || purchased.Currency != received.Purchased.Currency {
 		return false, errors.New(fmt.Sprintf("PurchasePrice field '%s' did not match expected '%s'", received.Purchased.Price, expected[10]))
 	}
 
-	// if expected[11] != received.Ratings {
-	// return false, errors.New(fmt.Sprintf("Ratings field '%s' did not match expected '%s'", received.Ratings, expected[11]))
-	// }
+	ratings, _ := parseProductRatings(expected[11])
+	if !ratingsEqual(ratings, received.Ratings) {
+		return false, errors.New(fmt.Sprintf("Ratings field '%v' did not match expected '%s'", received.Ratings, expected[11]))
+	}
 
-	// if expected[12] != received.Comments {
-	// return false, errors.New(fmt.Sprintf("Comments field '%s' did not match expected '%s'", received.Comments, expected[12]))
-	// }
+	if expected[12] != received.Comments {
+		return false, errors.New(fmt.Sprintf("Comments field '%s' did not match expected '%s'", received.Comments, expected[12]))
+	}
 
 	// if expected[13] != received.Pictures {
 	// return false, errors.New(fmt.Sprintf("Pictures field '%s' did not match expected '%s'", received.Pictures, expected[13]))
@@ -266,7 +273,7 @@ func isTsvEqualToTea(expected []string, received *Tea) (bool, error) {
 		return false, errors.New(fmt.Sprintf("Country field '%s' did not match expected '%s'", received.Origin.Country, expected[14]))
 	}
 
-	if expected[15] != received.LeafGrade {
+	if expected[15] != received.LeafGrade.String() {
 		return false, errors.New(fmt.Sprintf("LeafGrade field '%s' did not match expected '%s'", received.LeafGrade, expected[15]))
 	}
 
@@ -304,7 +311,7 @@ func isTsvEqualToTea(expected []string, received *Tea) (bool, error) {
 func TestCreateTsvEntry(t *testing.T) {
 	original_entry := testTsvEntries[0]
 
-	e, err := newEntryFromTsv(original_entry)
+	e, _, err := newEntryFromTsv(original_entry)
 	if err != nil {
 		t.Fatalf("Unable to create Entry: %s\n", err)
 	}
@@ -315,9 +322,13 @@ func TestCreateTsvEntry(t *testing.T) {
 }
 
 func TestCreateTsvBadEntry(t *testing.T) {
-	if _, err := newEntryFromTsv([]string{time.Now().String(), "TEST"}); err == nil {
+	if _, _, err := newEntryFromTsv([]string{time.Now().String(), "TEST"}); err == nil {
 		t.Fatal("Successfully created badly formatted entry")
 	}
+
+	if _, _, err := newEntryFromTsv(testTsvEntries[0][:11]); err == nil {
+		t.Fatal("Successfully created entry without a Fixins column")
+	}
 }
 
 func TestCreateTsvTea(t *testing.T) {
@@ -331,6 +342,29 @@ func TestCreateTsvTea(t *testing.T) {
 	if _, err := isTsvEqualToTea(original_tea, tea); err != nil {
 		t.Errorf("Tea object does not match expected: %s", err)
 	}
+
+	measured_tea := append(append([]string{}, original_tea...), "4.5")
+	if tea, err = newTeaFromTsv(measured_tea); err != nil {
+		t.Fatal(err)
+	}
+	if tea.LeafPerSession != 4.5 || tea.SessionLeaf() != 4.5 {
+		t.Errorf("Expected 4.5g of leaf per session but found %f", tea.LeafPerSession)
+	}
+	if teaToTsv(*tea)[22] != "4.5" {
+		t.Errorf("Leaf per session was not exported: %v", teaToTsv(*tea))
+	}
+
+	measured_tea[22] = "lots"
+	if _, err := newTeaFromTsv(measured_tea); err == nil {
+		t.Error("Successfully created tea with a bad Leaf Per Session")
+	}
+
+	// A price without a currency is exported with the default currency, so that it does not depend on the importer
+	tea.Purchased.Price = 12.5
+	tea.Purchased.Currency = ""
+	if price := teaToTsv(*tea)[10]; price != "12.5 "+DefaultCurrency {
+		t.Errorf("Expected the price to be exported as '12.5 %s' but found '%s'", DefaultCurrency, price)
+	}
 }
 
 func TestCreateTsvBadTea(t *testing.T) {
@@ -357,6 +391,15 @@ func TestCreateTsvBadTea(t *testing.T) {
 		t.Fatal("Successfully created tea with bad Flush")
 	}
 
+	// A purchase date which is not recognized is kept as text
+	copy(bad_tea, testTsvTeas[0])
+	bad_tea[9] = "Monsoon"
+	if tea, err := newTeaFromTsv(bad_tea); err != nil {
+		t.Fatal(err)
+	} else if !tea.Purchased.Date.IsZero() || tea.Purchased.DateText != "Monsoon" || teaToTsv(*tea)[9] != "Monsoon" {
+		t.Errorf("Purchase Date was not kept as text: %+v", tea.Purchased)
+	}
+
 	copy(bad_tea, testTsvTeas[0])
 	bad_tea[10] = "Monsoon"
 	if _, err := newTeaFromTsv(bad_tea); err == nil {
@@ -370,6 +413,81 @@ func TestCreateTsvBadTea(t *testing.T) {
 	}
 }
 
+func TestParseProductRatings(t *testing.T) {
+	ratings, err := parseProductRatings("value:3; Leaf Aroma :4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ratings["Value"] != 3 || ratings["Leaf Aroma"] != 4 || len(ratings) != 2 {
+		t.Errorf("Unexpected named product ratings: %v", ratings)
+	}
+
+	if ratings, err = parseProductRatings("1;;2"); err != nil {
+		t.Fatal(err)
+	}
+	if ratings["Value"] != 1 || ratings["Brewed Aroma"] != 2 || len(ratings) != 2 {
+		t.Errorf("Unexpected positional product ratings: %v", ratings)
+	}
+
+	if ratings, err = parseProductRatings(""); err != nil || len(ratings) != 0 {
+		t.Errorf("Unexpected product ratings from an empty value: %v, %v", ratings, err)
+	}
+
+	if _, err = parseProductRatings("Bitterness:3"); err == nil {
+		t.Error("Successfully parsed an unknown product rating")
+	}
+
+	if _, err = parseProductRatings("1;2;3;4"); err == nil {
+		t.Error("Successfully parsed too many product ratings")
+	}
+
+	if _, err = parseProductRatings("Value:good"); err == nil {
+		t.Error("Successfully parsed a non-numeric product rating")
+	}
+}
+
+func TestCreateTsvBlendedTea(t *testing.T) {
+	blended_tea := make([]string, len(testTsvTeas[0]))
+	copy(blended_tea, testTsvTeas[0])
+	blended_tea[16] = "1;2"
+	blended_tea[17] = "3:1"
+
+	tea, err := newTeaFromTsv(blended_tea)
+	if err != nil {
+		t.Fatalf("Unable to create Tea: %s\n", err)
+	}
+
+	expected := TeaBlend{{Tea: 1, Ratio: 3}, {Tea: 2, Ratio: 1}}
+	if !tea.Blend.Equal(expected) {
+		t.Fatalf("Expected blend %v but found %v", expected, tea.Blend)
+	}
+
+	blended_tea[17] = ""
+	if tea, err = newTeaFromTsv(blended_tea); err != nil {
+		t.Fatalf("Unable to create Tea without a blend ratio: %s\n", err)
+	}
+
+	if share := tea.Blend.Shares()[1]; share != 0.5 {
+		t.Errorf("Expected an even share without a blend ratio but found %f", share)
+	}
+
+	blended_tea[16] = "1;two"
+	if _, err := newTeaFromTsv(blended_tea); err == nil {
+		t.Error("Successfully created tea with a bad blended tea id")
+	}
+
+	blended_tea[16] = "1;2"
+	blended_tea[17] = "3"
+	if _, err := newTeaFromTsv(blended_tea); err == nil {
+		t.Error("Successfully created tea with a blend ratio that does not match the blended teas")
+	}
+
+	blended_tea[17] = "3;lots"
+	if _, err := newTeaFromTsv(blended_tea); err == nil {
+		t.Error("Successfully created tea with a bad blend ratio")
+	}
+}
+
 func getTsvServer(data [][]string) *httptest.Server {
 	// TODO:
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -450,6 +568,115 @@ func TestGetSheetTsvProxy(t *testing.T) {
 	}
 }
 
+func TestCreateTsvEntryByName(t *testing.T) {
+	named_entry := make([]string, len(testTsvEntries[0]))
+	copy(named_entry, testTsvEntries[0])
+	named_entry[8] = "Gaiwan"
+	named_entry[11] = "milk;6"
+
+	e, _, err := newEntryFromTsv(named_entry)
+	if err != nil {
+		t.Fatalf("Unable to create Entry: %s\n", err)
+	}
+
+	if e.SteepingVessel != Gaiwan {
+		t.Errorf("Expected vessel %s but found %s", Gaiwan, e.SteepingVessel)
+	}
+
+	if len(e.Fixins) != 2 || e.Fixins[0] != Milk || e.Fixins[1] != Honey {
+		t.Errorf("Unexpected fixins: %v", e.Fixins)
+	}
+
+	// An unknown vessel is loaded as Other and unknown fixins are left out, which are both reported
+	named_entry[8] = "Samovar"
+	named_entry[11] = "Lemon;milk"
+	e, warnings, err := newEntryFromTsv(named_entry)
+	if err != nil {
+		t.Fatalf("Unable to create Entry: %s\n", err)
+	}
+	if e.SteepingVessel != Other || e.Vessel != "" || len(e.Fixins) != 1 || e.Fixins[0] != Milk {
+		t.Errorf("Unknown vessel and fixin were not left out: %s %v", e.SteepingVessel, e.Fixins)
+	}
+	if len(warnings) != 2 || !strings.Contains(warnings[0], "Samovar") || !strings.Contains(warnings[1], "Lemon") {
+		t.Errorf("Unknown vessel and fixin were not reported: %v", warnings)
+	}
+
+	// The database keeps the warnings with the rows they are about
+	teasTsv := append([][]string{testTsvTeasHeader}, testTsvTeas...)
+	db, err := newTeaDbFromTsv(teasTsv, [][]string{testTsvEntriesHeader, testTsvEntries[0], named_entry})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := db.Warnings(); len(w) != 2 || w[0].(TsvWarning).Row != 3 || !strings.Contains(w[0].Error(), "Journal row 3") {
+		t.Errorf("Unexpected warnings: %v", w)
+	}
+}
+
+func TestCreateTsvEntryMeasured(t *testing.T) {
+	measured_entry := append(append([]string{}, testTsvEntries[0]...), "5.5", "150")
+
+	e, _, err := newEntryFromTsv(measured_entry)
+	if err != nil {
+		t.Fatalf("Unable to create Entry: %s\n", err)
+	}
+
+	if e.LeafGrams != 5.5 || e.WaterMl != 150 {
+		t.Errorf("Expected 5.5g of leaf in 150ml of water but found %.1fg in %dml", e.LeafGrams, e.WaterMl)
+	}
+
+	measured_entry[12] = "lots"
+	if _, _, err := newEntryFromTsv(measured_entry); err == nil {
+		t.Error("Successfully created entry with an invalid leaf amount")
+	}
+}
+
+func TestLoadLookupTsv(t *testing.T) {
+	defer delete(VesselTypes, VesselType(100))
+	defer delete(TeaFixins, TeaFixin(100))
+
+	vesselsServer := getTsvServer([][]string{
+		[]string{"ID", "Name", "Volume"},
+		[]string{"100", "Kyusu", "250"},
+	})
+	defer vesselsServer.Close()
+
+	if err := LoadVesselTypesTsv(vesselsServer.URL, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if VesselType(100).String() != "Kyusu" || VesselType(100).Volume() != 250 {
+		t.Errorf("Vessel type was not loaded from the lookup sheet: %+v", VesselTypes[100])
+	}
+
+	fixinsServer := getTsvServer([][]string{
+		[]string{"ID", "Name", "Category"},
+		[]string{"100", "Oat milk", "Dairy"},
+	})
+	defer fixinsServer.Close()
+
+	if err := LoadTeaFixinsTsv(fixinsServer.URL, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	if TeaFixin(100).String() != "Oat milk" || TeaFixin(100).Category() != Dairy {
+		t.Errorf("Fixin was not loaded from the lookup sheet: %+v", TeaFixins[100])
+	}
+
+	badServer := getTsvServer([][]string{
+		[]string{"ID", "Name", "Category"},
+		[]string{"one", "Lemon", "Flavoring"},
+	})
+	defer badServer.Close()
+
+	if err := LoadVesselTypesTsv(badServer.URL, ""); err == nil {
+		t.Error("Did not receive expected error on a bad vessel id")
+	}
+
+	if err := LoadTeaFixinsTsv(badServer.URL, ""); err == nil {
+		t.Error("Did not receive expected error on a bad fixin id")
+	}
+}
+
 func TestNewFromTsv(t *testing.T) {
 	tsvTeasServer := getTsvServer(append([][]string{testTsvTeasHeader}, testTsvTeas...))
 	defer tsvTeasServer.Close()
@@ -462,6 +689,10 @@ func TestNewFromTsv(t *testing.T) {
 		t.Fatal(err)
 	}
 
+	if !db.ReadOnly() {
+		t.Error("The published sheets are not read-only")
+	}
+
 	teas, err := db.Teas(NewFilter())
 	if err != nil {
 		t.Error(err)
@@ -493,6 +724,44 @@ func TestNewFromTsv(t *testing.T) {
 	}
 }
 
+func TestNewFromTsvEntryByTeaName(t *testing.T) {
+	tsvTeasServer := getTsvServer(append([][]string{testTsvTeasHeader}, testTsvTeas...))
+	defer tsvTeasServer.Close()
+
+	named_entry := append([]string{}, testTsvEntries[0]...)
+	named_entry[3] = "2016 name"
+	tsvEntriesServer := getTsvServer([][]string{testTsvEntriesHeader, named_entry})
+	defer tsvEntriesServer.Close()
+
+	db, err := NewFromTsv(tsvTeasServer.URL, tsvEntriesServer.URL, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	log, _ := db.Log(NewFilter())
+	if len(log) != 1 || log[0].Tea != 42 {
+		t.Errorf("Expected the entry to be logged for tea 42: %v", log)
+	}
+
+	// Only exact names are matched, and the rows of other names are reported and left out
+	unknown_entry := append([]string{}, named_entry...)
+	unknown_entry[3] = "Sencha"
+	typo_entry := append([]string{}, named_entry...)
+	typo_entry[3] = "2016 nmae"
+	unknownServer := getTsvServer([][]string{testTsvEntriesHeader, named_entry, unknown_entry, typo_entry})
+	defer unknownServer.Close()
+
+	if db, err = NewFromTsv(tsvTeasServer.URL, unknownServer.URL, ""); err != nil {
+		t.Fatal(err)
+	}
+	if log, _ := db.Log(NewFilter()); len(log) != 1 || log[0].Tea != 42 {
+		t.Errorf("Expected only the entry with the exact name to be loaded: %v", log)
+	}
+	if w := db.Warnings(); len(w) != 2 || w[0].(TsvWarning).Row != 3 || w[1].(TsvWarning).Row != 4 {
+		t.Errorf("Unexpected warnings: %v", w)
+	}
+}
+
 func TestNewFromTsvFailure(t *testing.T) {
 	noEntriesServer := getTsvServer([][]string{[]string{}})
 	defer noEntriesServer.Close()
@@ -544,3 +813,177 @@ func TestNewFromTsvFailure(t *testing.T) {
 		t.Error("Did not receive expected error when incorrect number of journal fields")
 	}
 }
+
+func TestWriteTsvRoundTrip(t *testing.T) {
+	blended_tea := append([]string{}, testTsvTeas[0]...)
+	blended_tea[2] = "43"
+	blended_tea[3] = "Blend \"House\""
+	blended_tea[7] = "Autumn"
+	blended_tea[10] = "£12.50"
+	blended_tea[11] = "4;;5"
+	blended_tea[12] = "multi\nline; comments"
+	blended_tea[15] = "FTGFOP1"
+	blended_tea[16] = "42;44"
+	blended_tea[17] = "3:1"
+	blended_tea[18] = "100g"
+	other_tea := append([]string{}, testTsvTeas[0]...)
+	other_tea[2] = "44"
+	other_tea[6] = ""
+	other_tea[7] = ""
+	other_tea[9] = ""
+	other_tea[10] = ""
+	other_tea[11] = ""
+	other_tea = append(other_tea, "7.5")
+	teasTsv := append([][]string{testTsvTeasHeader}, testTsvTeas[0], blended_tea, other_tea)
+
+	measured_entry := append([]string{}, testTsvEntries[0]...)
+	measured_entry[1] = "7/4/2018"
+	measured_entry[2] = "005"
+	measured_entry[3] = "43"
+	measured_entry[7] = " 2m 30s"
+	measured_entry[11] = "6"
+	measured_entry = append(measured_entry, "5.5", "150")
+	journalTsv := [][]string{append(append([]string{}, testTsvEntriesHeader...), "Leaf Grams", "Water ml")}
+	for _, entry := range append(testTsvEntries, measured_entry) {
+		if len(entry) == len(testTsvEntriesHeader) {
+			entry = append(append([]string{}, entry...), "", "")
+		}
+		journalTsv = append(journalTsv, entry)
+	}
+
+	db, err := newTeaDbFromTsv(teasTsv, journalTsv)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var teas, journal bytes.Buffer
+	if err := db.WriteTsv(&teas, &journal); err != nil {
+		t.Fatal(err)
+	}
+	exportedTeas, err := readTsv(bytes.NewReader(teas.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	exportedJournal, err := readTsv(bytes.NewReader(journal.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := compareTsvArrays(exportedTeas[:1], [][]string{append(append([]string{}, testTsvTeasHeader...), "Leaf Per Session")}); err != nil {
+		t.Errorf("Teas header does not match: %s", err)
+	}
+	if len(exportedTeas) != len(teasTsv) || len(exportedJournal) != len(journalTsv) {
+		t.Fatalf("Exported %d teas and %d entries instead of expected: %d and %d", len(exportedTeas)-1, len(exportedJournal)-1, len(teasTsv)-1, len(journalTsv)-1)
+	}
+
+	reparsed, err := newTeaDbFromTsv(exportedTeas, exportedJournal)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for id, tea := range db.teas {
+		other, err := reparsed.Tea(id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !tea.Equal(&other) {
+			t.Errorf("Tea %d does not match after the round trip:\n%+v\n%+v", id, tea, other)
+		}
+	}
+
+	log, _ := db.Log(NewFilter())
+	reparsedLog, _ := reparsed.Log(NewFilter())
+	if len(log) != len(reparsedLog) {
+		t.Fatalf("Expected %d entries after the round trip but found %d", len(log), len(reparsedLog))
+	}
+	for i := range log {
+		if !log[i].Equal(&reparsedLog[i]) {
+			t.Errorf("Entry does not match after the round trip:\n%+v\n%+v", log[i], reparsedLog[i])
+		}
+	}
+
+	// Exporting the reparsed database gives back the same files
+	var teasAgain, journalAgain bytes.Buffer
+	if err := reparsed.WriteTsv(&teasAgain, &journalAgain); err != nil {
+		t.Fatal(err)
+	}
+	if teasAgain.String() != teas.String() || journalAgain.String() != journal.String() {
+		t.Error("Exporting the reparsed database did not give the same files")
+	}
+}
+
+func TestWriteTsvRoundTripSameMinute(t *testing.T) {
+	loc, _ := time.LoadLocation("America/New_York")
+	teas := []*Tea{{Id: 1, Name: "Dong Ding"}}
+	entries := []*Entry{
+		{Tea: 1, DateTime: time.Date(2018, 1, 1, 8, 0, 10, 0, loc), Rating: 2, SteepingTemperature: 195},
+		{Tea: 1, DateTime: time.Date(2018, 1, 1, 8, 0, 50, 0, loc), Rating: 4, SteepingTemperature: 195},
+	}
+	db, err := newTeaDb(teas, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var teasTsv, journalTsv bytes.Buffer
+	if err := db.WriteTsv(&teasTsv, &journalTsv); err != nil {
+		t.Fatal(err)
+	}
+	exportedTeas, _ := readTsv(bytes.NewReader(teasTsv.Bytes()))
+	exportedJournal, _ := readTsv(bytes.NewReader(journalTsv.Bytes()))
+
+	reparsed, err := newTeaDbFromTsv(exportedTeas, exportedJournal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log, _ := reparsed.Log(NewFilter())
+	if len(log) != len(entries) {
+		t.Fatalf("Expected %d entries after the round trip but found %d", len(entries), len(log))
+	}
+	for i, e := range entries {
+		if !e.Equal(&log[i]) {
+			t.Errorf("Entry does not match after the round trip:\n%+v\n%+v", e, log[i])
+		}
+	}
+}
+
+func TestNewFromTsvFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hgtealib")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := newTeaDbFromTsv(append([][]string{testTsvTeasHeader}, testTsvTeas...), append([][]string{testTsvEntriesHeader}, testTsvEntries...))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	teas, err := os.Create(filepath.Join(dir, "teas.tsv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal, err := os.Create(filepath.Join(dir, "journal.tsv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.WriteTsv(teas, journal); err != nil {
+		t.Fatal(err)
+	}
+	teas.Close()
+	journal.Close()
+
+	reloaded, err := NewFromTsv("file://"+filepath.Join(dir, "teas.tsv"), "file://"+filepath.Join(dir, "journal.tsv"), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if log, _ := reloaded.Log(NewFilter()); len(log) != len(testTsvEntries) {
+		t.Errorf("Expected %d entries but found %d", len(testTsvEntries), len(log))
+	}
+
+	if _, err := NewFromTsv(filepath.Join(dir, "teas.tsv"), filepath.Join(dir, "journal.tsv"), ""); err == nil {
+		t.Error("Did not receive an error when reading local files without the file:// scheme")
+	}
+	if _, err := NewFromTsv("htps://example.com/teas", "file://"+filepath.Join(dir, "journal.tsv"), ""); err == nil {
+		t.Error("Did not receive an error for a mistyped URL")
+	}
+}
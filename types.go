@@ -11,12 +11,6 @@ import (
 	"time"
 )
 
-/*
-var TeaFlushTypes = [ ["Spring", "Summer", "Fall", "Winter"],
-                      ["1st Flush", "2nd Flush", "Monsoon Flush", "Autumn Flush"] ];
-var TeaFlushTypes_Std = 0;
-var TeaFlushTypes_Indian = 1;
-*/
 type Flush float64
 
 const (
@@ -27,104 +21,91 @@ const (
 	Autumn    Flush = 4.0
 )
 
-func (f Flush) String() string {
-	switch {
-	case f == First:
-		return "First"
-	case f == InBetween:
-		return "InBetween"
-	case f == Second:
-		return "Second"
-	case f == Monsoon:
-		return "Monsoon"
-	case f == Autumn:
-		return "Autumn"
-	default:
-		return ""
-	}
-}
-
-type VesselType int
+type FlushNaming int
 
 const (
-	FrenchPress VesselType = 0 + iota
-	ShipiaoYixing
-	TeazerTumbler
-	TeaStick
-	MeshSpoon
-	SaucePan
-	Cup
-	Bowl
-	Gaiwan
-	Other
+	StandardFlushNaming FlushNaming = 0 + iota
+	SeasonalFlushNaming
+	IndianFlushNaming
 )
 
-func (v VesselType) String() string {
-	switch {
-	case v == FrenchPress:
-		return "French Press"
-	case v == ShipiaoYixing:
-		return "Shipiao Yixing"
-	case v == TeazerTumbler:
-		return "Tea-zer Tumbler"
-	case v == TeaStick:
-		return "Tea stick"
-	case v == MeshSpoon:
-		return "Mesh spoon"
-	case v == SaucePan:
-		return "Sauce pan"
-	case v == Cup:
-		return "Cup"
-	case v == Bowl:
-		return "Bowl"
-	case v == Gaiwan:
-		return "Gaiwan"
-	case v == Other:
-		return "Other"
+func (n FlushNaming) String() string {
+	switch n {
+	case StandardFlushNaming:
+		return "Standard"
+	case SeasonalFlushNaming:
+		return "Seasonal"
+	case IndianFlushNaming:
+		return "Indian"
 	default:
 		return ""
 	}
 }
 
-type TeaFixin int
+func ParseFlushNaming(s string) (FlushNaming, error) {
+	for _, n := range []FlushNaming{StandardFlushNaming, SeasonalFlushNaming, IndianFlushNaming} {
+		if strings.EqualFold(s, n.String()) {
+			return n, nil
+		}
+	}
+	return StandardFlushNaming, errors.New(fmt.Sprintf("Unknown flush naming scheme: %s", s))
+}
 
-const (
-	Milk TeaFixin = 0 + iota
-	Cream
-	HalfAndHalf
-	Sugar
-	BrownSugar
-	RawSugar
-	Honey
-	VanillaExtract
-	VanillaBean
-)
+var TeaFlushTypes = map[FlushNaming]map[Flush]string{
+	StandardFlushNaming: {First: "First", InBetween: "InBetween", Second: "Second", Monsoon: "Monsoon", Autumn: "Autumn"},
+	SeasonalFlushNaming: {First: "Spring", InBetween: "Late Spring", Second: "Summer", Monsoon: "Fall", Autumn: "Winter"},
+	IndianFlushNaming:   {First: "1st Flush", InBetween: "In-between Flush", Second: "2nd Flush", Monsoon: "Monsoon Flush", Autumn: "Autumn Flush"},
+}
 
-func (f TeaFixin) String() string {
-	switch {
-	case f == Milk:
-		return "Milk"
-	case f == Cream:
-		return "Cream"
-	case f == HalfAndHalf:
-		return "Half & half"
-	case f == Sugar:
-		return "Sugar"
-	case f == BrownSugar:
-		return "Brown sugar"
-	case f == RawSugar:
-		return "Raw sugar"
-	case f == Honey:
-		return "Honey"
-	case f == VanillaExtract:
-		return "Vanilla extract"
-	case f == VanillaBean:
-		return "Vanilla bean"
-	default:
-		return ""
+// FlushNamings maps the regions and countries of origin to the flush naming scheme used for their teas
+var FlushNamings = map[string]FlushNaming{
+	"China":  SeasonalFlushNaming,
+	"Taiwan": SeasonalFlushNaming,
+	"Japan":  SeasonalFlushNaming,
+	"Korea":  SeasonalFlushNaming,
+	"India":  IndianFlushNaming,
+	"Nepal":  IndianFlushNaming,
+}
+
+func (f Flush) Name(naming FlushNaming) string {
+	return TeaFlushTypes[naming][f]
+}
+
+func (f Flush) String() string {
+	return f.Name(StandardFlushNaming)
+}
+
+// ParseFlush accepts either the numeric value of a flush or its name in any of the naming schemes
+func ParseFlush(s string) (Flush, error) {
+	s = strings.TrimSpace(s)
+	if v, err := strconv.ParseFloat(s, 64); err == nil {
+		return Flush(v), nil
+	}
+
+	for _, names := range TeaFlushTypes {
+		for f, name := range names {
+			if strings.EqualFold(s, name) {
+				return f, nil
+			}
+		}
 	}
+
+	return 0, errors.New(fmt.Sprintf("Unknown flush: %s", s))
 }
 
+// TeaFlush is a flush which is named according to the naming scheme of the tea's origin
+type TeaFlush struct {
+	Flush
+	Naming FlushNaming
+}
+
+func (f TeaFlush) String() string {
+	return f.Flush.Name(f.Naming)
+}
+
+// MaxRating is the best rating of an entry or a product rating
+const MaxRating = 4
+
 // Timestamp       Date    Time    Tea     Rating  Comments        Pictures        Steep Time      Steeping Vessel Steep Temperature       Session Instance        Fixins
 type Entry struct {
 	Tea                 int
@@ -133,11 +114,23 @@ type Entry struct {
 	Comments            string
 	SteepTime           time.Duration
 	SteepingVessel      VesselType
+	Vessel              string
 	SteepingTemperature int
 	SessionInstance     string
 	Fixins              []TeaFixin
+	LeafGrams           float64
+	WaterMl             int
+	Tags                []Tag
 }
 
+// journalLocation is the zone of the dates and times of the journal
+func journalLocation() *time.Location {
+	// In theory, this should never result in an error
+	loc, _ := time.LoadLocation("America/New_York")
+	return loc
+}
+
+// ParseDateTime parses the date and the time, which is only to the minute
 func (e *Entry) ParseDateTime(d, t string) error {
 	// Validate the date field
 	if d == "" {
@@ -185,10 +178,22 @@ func (e *Entry) ParseDateTime(d, t string) error {
 		return err
 	}
 
-	// In theory, this should never result in an error
-	loc, _ := time.LoadLocation("America/New_York")
+	e.DateTime = time.Date(year, time.Month(month), day, hour, minute, 0, 0, journalLocation())
 
-	e.DateTime = time.Date(year, time.Month(month), day, hour, minute, 0, 0, loc)
+	return nil
+}
+
+// ParseTimestamp parses the full time of the entry, as written by an export, which must fall within the minute of
+// the date and time that were already parsed
+func (e *Entry) ParseTimestamp(ts string) error {
+	at, err := time.Parse(time.RFC3339Nano, ts)
+	if err != nil {
+		return err
+	}
+	if !at.Truncate(time.Minute).Equal(e.DateTime) {
+		return errors.New(fmt.Sprintf("Full time %s is not within %s", ts, e.DateTime.Format("1/2/2006 1504")))
+	}
+	e.DateTime = at.In(journalLocation())
 
 	return nil
 }
@@ -204,6 +209,52 @@ func (e *Entry) ParseSteepTime(d string) error {
 	return nil
 }
 
+// ParseVessel accepts the name of a vessel in the inventory, or else the numeric code or name of a vessel type
+func (e *Entry) ParseVessel(s string) error {
+	if v, ok := LookupVessel(s); ok {
+		e.Vessel = v.Name
+		e.SteepingVessel = v.Type
+		return nil
+	}
+
+	vessel, err := ParseVesselType(s)
+	if err != nil {
+		return err
+	}
+	e.Vessel = ""
+	e.SteepingVessel = vessel
+
+	return nil
+}
+
+// VesselName returns the name of the vessel in the inventory used for the entry, or else the type of vessel
+func (e *Entry) VesselName() string {
+	if e.Vessel != "" {
+		return e.Vessel
+	}
+	return e.SteepingVessel.String()
+}
+
+// EstimatedWater returns the milliliters of water recorded for the entry, or else estimates it from the volume of
+// its vessel
+func (e *Entry) EstimatedWater() int {
+	if e.WaterMl > 0 {
+		return e.WaterMl
+	}
+	if v, ok := LookupVessel(e.Vessel); ok {
+		return v.Volume
+	}
+	return e.SteepingVessel.Volume()
+}
+
+// Ratio returns the grams of leaf used per 100ml of water, or zero if the amount of leaf or water was not recorded
+func (e *Entry) Ratio() float64 {
+	if e.LeafGrams <= 0 || e.WaterMl <= 0 {
+		return 0
+	}
+	return e.LeafGrams / float64(e.WaterMl) * 100
+}
+
 func (e *Entry) Equal(other *Entry) bool {
 	return e.Tea == other.Tea &&
 		e.DateTime.Equal(other.DateTime) &&
@@ -211,9 +262,24 @@ func (e *Entry) Equal(other *Entry) bool {
 		e.Comments == other.Comments &&
 		e.SteepTime.Nanoseconds() == other.SteepTime.Nanoseconds() &&
 		e.SteepingVessel == other.SteepingVessel &&
+		e.Vessel == other.Vessel &&
 		e.SteepingTemperature == other.SteepingTemperature &&
 		e.SessionInstance == other.SessionInstance &&
-		(len(e.Fixins) == len(other.Fixins))
+		e.equalFixins(other) &&
+		e.LeafGrams == other.LeafGrams &&
+		e.WaterMl == other.WaterMl
+}
+
+func (e *Entry) equalFixins(other *Entry) bool {
+	if len(e.Fixins) != len(other.Fixins) {
+		return false
+	}
+	for i, f := range e.Fixins {
+		if f != other.Fixins[i] {
+			return false
+		}
+	}
+	return true
 }
 
 type TimeSlice []time.Time
@@ -248,9 +314,21 @@ func (o TeaOrigin) String() string {
 	return buf.String()
 }
 
+// FlushNaming returns the flush naming scheme of the region, or failing that the country, of the origin
+func (o TeaOrigin) FlushNaming() FlushNaming {
+	for _, place := range []string{o.Region, o.Country} {
+		for name, naming := range FlushNamings {
+			if place != "" && strings.EqualFold(name, place) {
+				return naming
+			}
+		}
+	}
+	return StandardFlushNaming
+}
+
 type TeaPickPeriod struct {
 	Year  int
-	Flush Flush
+	Flush TeaFlush
 }
 
 type TeaStorageState struct {
@@ -294,29 +372,264 @@ func (v TeaPackagingType) String() string {
 	}
 }
 
+// DefaultCurrency is the currency assumed for purchase prices that do not specify one
+var DefaultCurrency = "USD"
+
+var currencySymbols = map[string]string{
+	"$": "USD",
+	"€": "EUR",
+	"£": "GBP",
+}
+
+// ambiguousCurrencySymbols are used by more than one currency, so the code of the currency has to be given along
+// with them unless it is the default currency
+var ambiguousCurrencySymbols = map[string][]string{
+	"¥": {"CNY", "JPY"},
+}
+
+var re_thousandsComma = regexp.MustCompile(`^[0-9]{1,3}(,[0-9]{3})+(\.[0-9]+)?$`)
+var re_thousandsDot = regexp.MustCompile(`^[0-9]{1,3}(\.[0-9]{3})+,[0-9]+$`)
+var re_decimalComma = regexp.MustCompile(`^[0-9]+,[0-9]{1,2}$`)
+
+// parseAmount parses a number whose comma is either a thousands separator, as in "1,200.50", or the decimal
+// separator, as in "12,50" and "1.200,50"
+func parseAmount(v string) (float64, error) {
+	switch {
+	case re_thousandsComma.MatchString(v):
+		v = strings.Replace(v, ",", "", -1)
+	case re_thousandsDot.MatchString(v):
+		v = strings.Replace(strings.Replace(v, ".", "", -1), ",", ".", 1)
+	case re_decimalComma.MatchString(v):
+		v = strings.Replace(v, ",", ".", 1)
+	case strings.Contains(v, ","):
+		return 0, errors.New(fmt.Sprintf("Price has misplaced commas: %s", v))
+	}
+	return strconv.ParseFloat(v, 64)
+}
+
+func FormatPrice(price float64, currency string) string {
+	for symbol, c := range currencySymbols {
+		if c == currency {
+			return fmt.Sprintf("%s%.2f", symbol, price)
+		}
+	}
+	return fmt.Sprintf("%.2f %s", price, currency)
+}
+
 type TeaPurchaseInfo struct {
 	Location  string
-	Date      string
+	Date      time.Time
+	DateText  string
 	Price     float64
+	Currency  string
 	Packaging TeaPackagingType
 }
 
+// ParseDate parses the purchase date. A date which is not recognized is kept as it was entered in DateText.
+func (p *TeaPurchaseInfo) ParseDate(d string) error {
+	p.Date = time.Time{}
+	p.DateText = ""
+	if d == "" {
+		return nil
+	}
+
+	for _, layout := range []string{"1/2/2006", "2006-01-02"} {
+		if date, err := time.Parse(layout, strings.TrimSpace(d)); err == nil {
+			p.Date = date
+			return nil
+		}
+	}
+
+	p.DateText = d
+	return errors.New(fmt.Sprintf("Purchase date is invalid: %s", d))
+}
+
+// DateString returns the purchase date, or the text that was entered for it when it is not a recognized date
+func (p TeaPurchaseInfo) DateString() string {
+	if p.Date.IsZero() {
+		return p.DateText
+	}
+	return p.Date.Format("2006-01-02")
+}
+
+// ParsePrice parses a price such as "12.50", "$12.50" or "12.50 EUR"
+func (p *TeaPurchaseInfo) ParsePrice(v string) error {
+	v = strings.TrimSpace(v)
+	if v == "" {
+		p.Price = 0
+		p.Currency = ""
+		return nil
+	}
+
+	currency := DefaultCurrency
+	for symbol, c := range currencySymbols {
+		if strings.HasPrefix(v, symbol) {
+			currency = c
+			v = strings.TrimSpace(strings.TrimPrefix(v, symbol))
+			break
+		}
+	}
+
+	var ambiguous []string
+	for symbol, currencies := range ambiguousCurrencySymbols {
+		if strings.HasPrefix(v, symbol) {
+			ambiguous = currencies
+			v = strings.TrimSpace(strings.TrimPrefix(v, symbol))
+			break
+		}
+	}
+
+	if parts := strings.Fields(v); len(parts) == 2 {
+		currency = strings.ToUpper(parts[1])
+		v = parts[0]
+	} else if ambiguous != nil {
+		found := false
+		for _, c := range ambiguous {
+			found = found || c == DefaultCurrency
+		}
+		if !found {
+			return errors.New(fmt.Sprintf("The currency of the price is ambiguous, give one of %s, i.e.: %s %s", strings.Join(ambiguous, " or "), v, ambiguous[0]))
+		}
+	}
+
+	price, err := parseAmount(v)
+	if err != nil {
+		return err
+	}
+
+	p.Price = price
+	p.Currency = currency
+
+	return nil
+}
+
+// Month returns the year and month of the purchase, such as "2016-04"
+func (p TeaPurchaseInfo) Month() string {
+	if p.Date.IsZero() {
+		return ""
+	}
+	return p.Date.Format("2006-01")
+}
+
+// TeaProductRatings are the dimensions which a tea product can be rated on
+var TeaProductRatings = []string{"Value", "Leaf Aroma", "Brewed Aroma"}
+
+func productRatingName(name string) (string, bool) {
+	for _, r := range TeaProductRatings {
+		if strings.EqualFold(r, strings.TrimSpace(name)) {
+			return r, true
+		}
+	}
+	return "", false
+}
+
+type TeaBlendComponent struct {
+	Tea   int
+	Ratio float64
+}
+
+type TeaBlend []TeaBlendComponent
+
+// Shares returns the fraction of the blend that each component tea makes up
+func (b TeaBlend) Shares() map[int]float64 {
+	var total float64
+	for _, c := range b {
+		total += c.Ratio
+	}
+
+	shares := make(map[int]float64)
+	for _, c := range b {
+		if total == 0 {
+			shares[c.Tea] += 1 / float64(len(b))
+		} else {
+			shares[c.Tea] += c.Ratio / total
+		}
+	}
+	return shares
+}
+
+func (b TeaBlend) Equal(other TeaBlend) bool {
+	if len(b) != len(other) {
+		return false
+	}
+	for i, c := range b {
+		if c != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
 type Tea struct {
-	Id            int
-	Name          string
-	Type          string
-	Picked        TeaPickPeriod
-	Origin        TeaOrigin
-	Storage       TeaStorageState
-	Purchased     TeaPurchaseInfo
-	Size          string
-	LeafGrade     string // TODO: enum
-	log           map[time.Time]Entry
-	logSortedKeys TimeSlice
-	average       int
-	median        int
-	mode          int
-	// var TeaProductRatings = ["Value", "Leaf Aroma", "Brewed Aroma"];
+	Id             int
+	Name           string
+	Type           string
+	Picked         TeaPickPeriod
+	Origin         TeaOrigin
+	Storage        TeaStorageState
+	Purchased      TeaPurchaseInfo
+	Size           string
+	Quantity       float64
+	LeafPerSession float64
+	LeafGrade      TeaLeafGrade
+	Blend          TeaBlend
+	Ratings        map[string]int
+	Comments       string
+	log            map[time.Time]Entry
+	logSortedKeys  TimeSlice
+	average        int
+	median         int
+	mode           int
+}
+
+// CostPerSession divides the purchase price of the tea by the number of entries logged for it
+func (t *Tea) CostPerSession() float64 {
+	if len(t.log) == 0 {
+		return 0
+	}
+	return t.Purchased.Price / float64(len(t.log))
+}
+
+func (t *Tea) IsBlend() bool {
+	return len(t.Blend) > 0
+}
+
+var sizeUnits = map[string]float64{
+	"g":     1,
+	"gram":  1,
+	"grams": 1,
+	"kg":    1000,
+	"oz":    28.3495,
+	"lb":    453.592,
+}
+
+// ParseSize sets the size of the tea and, when it contains a known unit, its quantity in grams
+func (t *Tea) ParseSize(s string) {
+	t.Size = s
+	t.Quantity = 0
+
+	matches := regexp.MustCompile(`(?i)([0-9]*\.?[0-9]+)\s*(kg|grams|gram|g|oz|lb)\b`).FindStringSubmatch(s)
+	if matches == nil {
+		return
+	}
+
+	amount, _ := strconv.ParseFloat(matches[1], 64)
+	t.Quantity = amount * sizeUnits[strings.ToLower(matches[2])]
+}
+
+// Sessions returns the number of distinct sessions logged for the tea
+func (t *Tea) Sessions() int {
+	sessions := make(map[string]struct{})
+	var count int
+	for _, entry := range t.log {
+		if entry.SessionInstance == "" {
+			count++
+		} else if _, ok := sessions[entry.SessionInstance]; !ok {
+			sessions[entry.SessionInstance] = struct{}{}
+			count++
+		}
+	}
+	return count
 }
 
 func (t *Tea) Add(entry Entry) {
@@ -325,9 +638,28 @@ func (t *Tea) Add(entry Entry) {
 		t.logSortedKeys = make(TimeSlice, 0)
 	}
 
+	if _, ok := t.log[entry.DateTime]; !ok {
+		t.logSortedKeys = append(t.logSortedKeys, entry.DateTime)
+		sort.Sort(t.logSortedKeys)
+	}
 	t.log[entry.DateTime] = entry
-	t.logSortedKeys = append(t.logSortedKeys, entry.DateTime)
-	sort.Sort(t.logSortedKeys)
+	t.average, t.median, t.mode = 0, 0, 0
+}
+
+func (t *Tea) remove(at time.Time) {
+	for k := range t.log {
+		if k.Equal(at) {
+			delete(t.log, k)
+		}
+	}
+	keys := make(TimeSlice, 0, len(t.logSortedKeys))
+	for _, k := range t.logSortedKeys {
+		if !k.Equal(at) {
+			keys = append(keys, k)
+		}
+	}
+	t.logSortedKeys = keys
+	t.average, t.median, t.mode = 0, 0, 0
 }
 
 func (t *Tea) Log() []Entry {
@@ -394,6 +726,15 @@ func (t *Tea) Mode() int {
 	return t.mode
 }
 
+// RatingCounts returns the number of entries given each rating
+func (t *Tea) RatingCounts() map[int]int {
+	counts := make(map[int]int)
+	for _, entry := range t.log {
+		counts[entry.Rating]++
+	}
+	return counts
+}
+
 func (t *Tea) Equal(other *Tea) bool {
 	return t.Id == other.Id &&
 		t.Name == other.Name &&
@@ -401,18 +742,23 @@ func (t *Tea) Equal(other *Tea) bool {
 		t.Picked.Year == other.Picked.Year &&
 		t.Picked.Flush == other.Picked.Flush &&
 		t.Size == other.Size &&
+		t.Quantity == other.Quantity &&
+		t.LeafPerSession == other.LeafPerSession &&
 		t.Origin.Country == other.Origin.Country &&
 		t.Origin.Region == other.Origin.Region &&
 		t.Storage.Stocked == other.Storage.Stocked &&
 		t.Storage.Aging == other.Storage.Aging &&
 		t.Purchased.Location == other.Purchased.Location &&
-		t.Purchased.Date == other.Purchased.Date &&
+		t.Purchased.Date.Equal(other.Purchased.Date) &&
+		t.Purchased.DateText == other.Purchased.DateText &&
 		t.Purchased.Price == other.Purchased.Price &&
+		t.Purchased.Currency == other.Purchased.Currency &&
 		t.Purchased.Packaging == other.Purchased.Packaging &&
-		t.LeafGrade == other.LeafGrade
+		t.LeafGrade == other.LeafGrade &&
+		t.Blend.Equal(other.Blend) &&
+		ratingsEqual(t.Ratings, other.Ratings) &&
+		t.Comments == other.Comments
 	/*
-		t.LeafGrade     string // TODO: enum
-
 		t.log           map[time.Time]Entry
 		t.logSortedKeys TimeSlice
 		t.average       int
@@ -421,6 +767,18 @@ func (t *Tea) Equal(other *Tea) bool {
 	*/
 }
 
+func ratingsEqual(r1, r2 map[string]int) bool {
+	if len(r1) != len(r2) {
+		return false
+	}
+	for k, v := range r1 {
+		if other, ok := r2[k]; !ok || other != v {
+			return false
+		}
+	}
+	return true
+}
+
 func (t *Tea) String() string {
 	var buf bytes.Buffer
 
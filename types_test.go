@@ -30,7 +30,7 @@ var testTeas = []*Tea{
 		Type: "Black Flavored",
 		Picked: TeaPickPeriod{
 			Year:  2009,
-			Flush: InBetween,
+			Flush: TeaFlush{Flush: InBetween, Naming: IndianFlushNaming},
 		},
 		Origin: TeaOrigin{
 			Country: "India",
@@ -42,12 +42,14 @@ var testTeas = []*Tea{
 		},
 		Purchased: TeaPurchaseInfo{
 			Location:  "testing.com",
-			Date:      "1/2/2009",
+			Date:      time.Date(2009, 1, 2, 0, 0, 0, 0, time.UTC),
 			Price:     1234.56,
+			Currency:  "USD",
 			Packaging: 0,
 		},
 		Size:      "2oz sample",
-		LeafGrade: "STFTGFOPOMG!",
+		LeafGrade: ParseLeafGrade("STFTGFOPOMG!"),
+		Ratings:   map[string]int{"Value": 4, "Leaf Aroma": 2},
 		// log           map[time.Time]Entry
 		// logSortedKeys TimeSlice
 		// average       int
@@ -70,12 +72,13 @@ var testTeas = []*Tea{
 		},
 		Purchased: TeaPurchaseInfo{
 			Location:  "testing.com",
-			Date:      "11/14/2010",
+			Date:      time.Date(2010, 11, 14, 0, 0, 0, 0, time.UTC),
 			Price:     19.99,
+			Currency:  "USD",
 			Packaging: 0,
 		},
 		Size:      "2oz",
-		LeafGrade: "OP",
+		LeafGrade: ParseLeafGrade("OP"),
 		// log           map[time.Time]Entry
 		// logSortedKeys TimeSlice
 		// average       int
@@ -124,17 +127,17 @@ func createRandomTea(withEntries bool) *Tea {
 	t.Name = createRandomString(1)
 	t.Type = createRandomString(1)
 	t.Picked.Year = r.Int()
-	t.Picked.Flush = Flush(r.Intn(5))
+	t.Picked.Flush = TeaFlush{Flush: Flush(r.Intn(5))}
 	t.Origin.Country = createRandomString(1)
 	t.Origin.Region = createRandomString(1)
 	t.Storage.Stocked = ((r.Int() % 2) == 0)
 	t.Storage.Aging = ((r.Int() % 2) == 0)
 	t.Purchased.Location = createRandomString(1)
-	t.Purchased.Date = time.Now().Format("1/02/2009")
+	t.Purchased.Date = time.Now()
 	t.Purchased.Price = r.Float64()
 	t.Purchased.Packaging = TeaPackagingType(r.Intn(7))
 	t.Size = createRandomString(1)
-	t.LeafGrade = createRandomString(1)
+	t.LeafGrade = ParseLeafGrade(createRandomString(1))
 
 	t.log = make(map[time.Time]Entry)
 	t.logSortedKeys = make(TimeSlice, 0)
@@ -172,6 +175,74 @@ func TestFlushString(t *testing.T) {
 	}
 }
 
+func TestFlushName(t *testing.T) {
+	for _, n := range []FlushNaming{StandardFlushNaming, SeasonalFlushNaming, IndianFlushNaming} {
+		for _, v := range []Flush{First, InBetween, Second, Monsoon, Autumn} {
+			if v.Name(n) == "" {
+				t.Errorf("Flush did not return a useful %s name", n)
+			}
+		}
+	}
+
+	if v := First.Name(SeasonalFlushNaming); v != "Spring" {
+		t.Errorf("Expected 'Spring' but found '%s'", v)
+	}
+
+	if v := First.Name(IndianFlushNaming); v != "1st Flush" {
+		t.Errorf("Expected '1st Flush' but found '%s'", v)
+	}
+}
+
+func TestParseFlush(t *testing.T) {
+	tests := map[string]Flush{
+		"2":            Second,
+		"1.5":          InBetween,
+		"spring":       First,
+		"1st Flush":    First,
+		"Monsoon":      Monsoon,
+		" Summer":      Second,
+		"Autumn Flush": Autumn,
+	}
+
+	for s, expected := range tests {
+		f, err := ParseFlush(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f != expected {
+			t.Errorf("Expected '%s' to be parsed as %s but found %s", s, expected, f)
+		}
+	}
+
+	if _, err := ParseFlush("Sometime"); err == nil {
+		t.Error("Incorrectly parsed an unknown flush")
+	}
+}
+
+func TestFlushNaming(t *testing.T) {
+	if n, err := ParseFlushNaming("indian"); err != nil || n != IndianFlushNaming {
+		t.Errorf("Could not parse the Indian flush naming scheme: %s, %v", n, err)
+	}
+
+	if _, err := ParseFlushNaming("Martian"); err == nil {
+		t.Error("Incorrectly parsed an unknown flush naming scheme")
+	}
+
+	oolong := TeaFlush{Flush: First, Naming: TeaOrigin{Country: "Taiwan", Region: "Nantou"}.FlushNaming()}
+	if oolong.String() != "Spring" {
+		t.Errorf("Expected a Taiwanese first flush to be 'Spring' but found '%s'", oolong)
+	}
+
+	darjeeling := TeaFlush{Flush: First, Naming: TeaOrigin{Country: "India", Region: "Darjeeling"}.FlushNaming()}
+	if darjeeling.String() != "1st Flush" {
+		t.Errorf("Expected a Darjeeling first flush to be '1st Flush' but found '%s'", darjeeling)
+	}
+
+	if n := (TeaOrigin{Country: "Kenya"}).FlushNaming(); n != StandardFlushNaming {
+		t.Errorf("Expected the standard flush naming scheme but found %s", n)
+	}
+}
+
 func TestVesselTypeString(t *testing.T) {
 	for _, v := range []VesselType{FrenchPress, ShipiaoYixing, TeazerTumbler, TeaStick, MeshSpoon, SaucePan, Cup, Bowl, Gaiwan, Other} {
 		if v.String() == "" {
@@ -219,6 +290,103 @@ func TestTeaPackagingString(t *testing.T) {
 	}
 }
 
+func TestPurchaseParseDate(t *testing.T) {
+	var p TeaPurchaseInfo
+
+	for _, d := range []string{"4/15/2016", "2016-04-15"} {
+		if err := p.ParseDate(d); err != nil {
+			t.Fatal(err)
+		}
+
+		if !p.Date.Equal(time.Date(2016, 4, 15, 0, 0, 0, 0, time.UTC)) {
+			t.Errorf("Purchase date '%s' was parsed as %s", d, p.Date)
+		}
+	}
+
+	if p.Month() != "2016-04" {
+		t.Errorf("Expected purchase month 2016-04 but found %s", p.Month())
+	}
+
+	if err := p.ParseDate(""); err != nil || !p.Date.IsZero() || p.Month() != "" {
+		t.Errorf("Empty purchase date was not parsed as a zero time: %s, %v", p.Date, err)
+	}
+
+	if p.ParseDate("15/4/2016") == nil {
+		t.Error("Incorrectly parsed a purchase date with a bogus month")
+	}
+
+	if p.ParseDate("yesterday") == nil {
+		t.Error("Incorrectly parsed a string instead of a purchase date")
+	}
+	if !p.Date.IsZero() || p.DateText != "yesterday" || p.DateString() != "yesterday" {
+		t.Errorf("Unrecognized purchase date was not kept as text: %s, %q", p.Date, p.DateText)
+	}
+
+	if p.ParseDate("2016-04-15"); p.DateText != "" || p.DateString() != "2016-04-15" {
+		t.Errorf("Purchase date text was not cleared: %q", p.DateText)
+	}
+}
+
+func TestPurchaseParsePrice(t *testing.T) {
+	var p TeaPurchaseInfo
+
+	tests := []struct {
+		value    string
+		price    float64
+		currency string
+	}{
+		{"12.50", 12.50, DefaultCurrency},
+		{"$12.50", 12.50, "USD"},
+		{"€ 8", 8, "EUR"},
+		{"1,200 jpy", 1200, "JPY"},
+		{"12,50 EUR", 12.50, "EUR"},
+		{"1,200.50 USD", 1200.50, "USD"},
+		{"1.200,50 EUR", 1200.50, "EUR"},
+		{"¥1200 JPY", 1200, "JPY"},
+		{"¥30 cny", 30, "CNY"},
+		{"", 0, ""},
+	}
+
+	for _, test := range tests {
+		if err := p.ParsePrice(test.value); err != nil {
+			t.Fatal(err)
+		}
+
+		if p.Price != test.price || p.Currency != test.currency {
+			t.Errorf("Expected '%s' to be parsed as %f %s but found %f %s", test.value, test.price, test.currency, p.Price, p.Currency)
+		}
+	}
+
+	if p.ParsePrice("cheap") == nil {
+		t.Error("Incorrectly parsed a string instead of a price")
+	}
+
+	if p.ParsePrice("12,5,0 EUR") == nil {
+		t.Error("Incorrectly parsed a price with misplaced commas")
+	}
+
+	if p.ParsePrice("¥1200") == nil {
+		t.Error("Incorrectly parsed a yen price without its currency")
+	}
+
+	currency := DefaultCurrency
+	DefaultCurrency = "JPY"
+	defer func() { DefaultCurrency = currency }()
+	if err := p.ParsePrice("¥1200"); err != nil || p.Price != 1200 || p.Currency != "JPY" {
+		t.Errorf("Expected '¥1200' to be parsed as 1200 JPY but found %f %s: %v", p.Price, p.Currency, err)
+	}
+}
+
+func TestFormatPrice(t *testing.T) {
+	if v := FormatPrice(12.5, "USD"); v != "$12.50" {
+		t.Errorf("Expected $12.50 but found %s", v)
+	}
+
+	if v := FormatPrice(1200, "JPY"); v != "1200.00 JPY" {
+		t.Errorf("Expected 1200.00 JPY but found %s", v)
+	}
+}
+
 func TestEntryEquality(t *testing.T) {
 	if !testEntries[0].Equal(testEntries[0]) {
 		t.Error("Entry equality identity test failed")
@@ -227,6 +395,12 @@ func TestEntryEquality(t *testing.T) {
 	if createRandomEntry().Equal(createRandomEntry()) {
 		t.Error("Entry equality test with random data failed")
 	}
+
+	e1 := Entry{Tea: 1, Fixins: []TeaFixin{Milk, Honey}}
+	e2 := Entry{Tea: 1, Fixins: []TeaFixin{Milk, Sugar}}
+	if e1.Equal(&e2) {
+		t.Error("Entries with different fixins are equal")
+	}
 }
 
 func TestEntryParseDateTime(t *testing.T) {
@@ -307,6 +481,32 @@ func TestEntryParseDateTime(t *testing.T) {
 	}
 }
 
+func TestEntryParseTimestamp(t *testing.T) {
+	e := createRandomEntry()
+
+	if err := e.ParseDateTime("7/4/2018", "905"); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.ParseTimestamp("2018-07-04T09:05:42.5-04:00"); err != nil {
+		t.Error(err)
+	}
+	if e.DateTime.Second() != 42 || e.DateTime.Nanosecond() != 500000000 || e.DateTime.Minute() != 5 {
+		t.Errorf("Timestamp was not used: %s", e.DateTime)
+	}
+
+	e.ParseDateTime("7/4/2018", "905")
+	if e.ParseTimestamp("2018-07-04T10:05:42-04:00") == nil {
+		t.Error("Did not receive an error for a timestamp outside of the minute")
+	}
+	if e.DateTime.Second() != 0 || e.DateTime.Hour() != 9 {
+		t.Errorf("Timestamp outside of the minute was used: %s", e.DateTime)
+	}
+
+	if e.ParseTimestamp("7/4/2018 9:05:42") == nil {
+		t.Error("Did not receive an error for a timestamp of the form")
+	}
+}
+
 func TestEntryParseSteepTime(t *testing.T) {
 	e := createRandomEntry()
 
@@ -333,6 +533,34 @@ func TestEntryParseSteepTime(t *testing.T) {
 	}
 }
 
+func TestEntryParseVessel(t *testing.T) {
+	defer delete(Vessels, "Zhuni pot")
+	if err := AddVessel(Vessel{Name: "Zhuni pot", Type: ShipiaoYixing}); err != nil {
+		t.Fatal(err)
+	}
+
+	e := createRandomEntry()
+	if err := e.ParseVessel("zhuni pot"); err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Vessel != "Zhuni pot" || e.SteepingVessel != ShipiaoYixing || e.VesselName() != "Zhuni pot" {
+		t.Errorf("Entry did not link to the vessel from the inventory: %s, %s", e.Vessel, e.SteepingVessel)
+	}
+
+	if err := e.ParseVessel("8"); err != nil {
+		t.Fatal(err)
+	}
+
+	if e.Vessel != "" || e.SteepingVessel != Gaiwan || e.VesselName() != Gaiwan.String() {
+		t.Errorf("Entry did not parse the vessel type: %s, %s", e.Vessel, e.SteepingVessel)
+	}
+
+	if e.ParseVessel("Samovar") == nil {
+		t.Error("Incorrectly parsed an unknown vessel")
+	}
+}
+
 func TestTeaEquality(t *testing.T) {
 	if !testTeas[0].Equal(testTeas[0]) {
 		t.Error("Tea equality identity test failed")
@@ -447,8 +675,120 @@ func TestTeaMode(t *testing.T) {
 	}
 }
 
+func TestTeaRatingCounts(t *testing.T) {
+	tea := createRandomTea(true)
+
+	counts := tea.RatingCounts()
+	var total int
+	for rating, count := range counts {
+		total += count
+		var expected int
+		for _, entry := range tea.log {
+			if entry.Rating == rating {
+				expected++
+			}
+		}
+		if count != expected {
+			t.Errorf("Counted %d entries rated %d instead of expected: %d", count, rating, expected)
+		}
+	}
+	if total != tea.LogLen() {
+		t.Errorf("Counted %d entries instead of expected: %d", total, tea.LogLen())
+	}
+}
+
+func TestTeaParseSize(t *testing.T) {
+	tests := map[string]float64{
+		"100g":       100,
+		"2oz sample": 56.699,
+		"1.5 kg":     1500,
+		"25 grams":   25,
+		"sample":     0,
+		"":           0,
+	}
+
+	for size, quantity := range tests {
+		var tea Tea
+		tea.ParseSize(size)
+
+		if tea.Size != size {
+			t.Errorf("Expected size '%s' but found '%s'", size, tea.Size)
+		}
+
+		if tea.Quantity != quantity {
+			t.Errorf("Expected '%s' to be parsed as %fg but found %fg", size, quantity, tea.Quantity)
+		}
+	}
+}
+
+func TestTeaSessions(t *testing.T) {
+	tea := createRandomTea(false)
+
+	tea.Add(Entry{DateTime: time.Unix(1, 0), SessionInstance: "A"})
+	tea.Add(Entry{DateTime: time.Unix(2, 0), SessionInstance: "A"})
+	tea.Add(Entry{DateTime: time.Unix(3, 0), SessionInstance: "B"})
+	tea.Add(Entry{DateTime: time.Unix(4, 0)})
+	tea.Add(Entry{DateTime: time.Unix(5, 0)})
+
+	if tea.Sessions() != 4 {
+		t.Errorf("Expected 4 sessions but found %d", tea.Sessions())
+	}
+}
+
+func TestTeaCostPerSession(t *testing.T) {
+	tea := createRandomTea(true)
+
+	if expected := tea.Purchased.Price / float64(tea.LogLen()); tea.CostPerSession() != expected {
+		t.Errorf("Expected cost per session of %f but found %f", expected, tea.CostPerSession())
+	}
+
+	if tea = createRandomTea(false); tea.CostPerSession() != 0 {
+		t.Errorf("Expected no cost per session without entries but found %f", tea.CostPerSession())
+	}
+}
+
+func TestTeaBlendShares(t *testing.T) {
+	shares := TeaBlend{{Tea: 1, Ratio: 60}, {Tea: 2, Ratio: 30}, {Tea: 1, Ratio: 10}}.Shares()
+
+	if shares[1] != 0.7 || shares[2] != 0.3 {
+		t.Errorf("Unexpected blend shares: %v", shares)
+	}
+
+	shares = TeaBlend{{Tea: 1}, {Tea: 2}}.Shares()
+	if shares[1] != 0.5 || shares[2] != 0.5 {
+		t.Errorf("Expected even blend shares without ratios but found: %v", shares)
+	}
+
+	if len(TeaBlend{}.Shares()) != 0 {
+		t.Error("Found shares in an empty blend")
+	}
+}
+
 func TestTeaString(t *testing.T) {
 	if createRandomTea(false).String() == "" {
 		t.Error("Tea String() function returned empty string")
 	}
 }
+
+func TestEntryRatio(t *testing.T) {
+	e := Entry{SteepingVessel: Gaiwan, LeafGrams: 6}
+	if e.EstimatedWater() != Gaiwan.Volume() {
+		t.Errorf("Expected the water to be estimated from the vessel volume %d but found %d", Gaiwan.Volume(), e.EstimatedWater())
+	}
+	if ratio := e.Ratio(); ratio != 0 {
+		t.Errorf("Found ratio %f for an entry without a water amount", ratio)
+	}
+
+	e.WaterMl = 200
+	if e.EstimatedWater() != 200 {
+		t.Errorf("Expected the recorded water of 200 but found %d", e.EstimatedWater())
+	}
+	if ratio := e.Ratio(); ratio != 3 {
+		t.Errorf("Expected ratio of 3 but found %f", ratio)
+	}
+
+	e.LeafGrams = 0
+	if ratio := e.Ratio(); ratio != 0 {
+		t.Errorf("Found ratio %f for an entry without a leaf amount", ratio)
+	}
+}
@@ -0,0 +1,274 @@
+package hgtealib
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type VesselType int
+
+const (
+	FrenchPress VesselType = 0 + iota
+	ShipiaoYixing
+	TeazerTumbler
+	TeaStick
+	MeshSpoon
+	SaucePan
+	Cup
+	Bowl
+	Gaiwan
+	Other
+)
+
+// VesselTypeDefinition describes a type of steeping vessel. The volume is in milliliters.
+type VesselTypeDefinition struct {
+	Id     VesselType
+	Name   string
+	Volume int
+}
+
+// VesselTypes are the known types of steeping vessels, which can be added to from the configuration or a lookup sheet
+var VesselTypes = map[VesselType]VesselTypeDefinition{
+	FrenchPress:   {FrenchPress, "French Press", 1000},
+	ShipiaoYixing: {ShipiaoYixing, "Shipiao Yixing", 200},
+	TeazerTumbler: {TeazerTumbler, "Tea-zer Tumbler", 450},
+	TeaStick:      {TeaStick, "Tea stick", 350},
+	MeshSpoon:     {MeshSpoon, "Mesh spoon", 350},
+	SaucePan:      {SaucePan, "Sauce pan", 500},
+	Cup:           {Cup, "Cup", 350},
+	Bowl:          {Bowl, "Bowl", 300},
+	Gaiwan:        {Gaiwan, "Gaiwan", 120},
+	Other:         {Other, "Other", 0},
+}
+
+func AddVesselType(def VesselTypeDefinition) error {
+	if strings.TrimSpace(def.Name) == "" {
+		return errors.New(fmt.Sprintf("Vessel type %d has no name", def.Id))
+	}
+	VesselTypes[def.Id] = def
+	return nil
+}
+
+// ParseVesselType accepts either the numeric code of a vessel type or its name
+func ParseVesselType(s string) (VesselType, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return VesselType(0), nil
+	}
+
+	if id, err := strconv.Atoi(s); err == nil {
+		return VesselType(id), nil
+	}
+
+	for id, def := range VesselTypes {
+		if strings.EqualFold(def.Name, s) {
+			return id, nil
+		}
+	}
+
+	return VesselType(0), errors.New(fmt.Sprintf("Unknown vessel type: %s", s))
+}
+
+func (v VesselType) Volume() int {
+	return VesselTypes[v].Volume
+}
+
+func (v VesselType) String() string {
+	return VesselTypes[v].Name
+}
+
+// Vessel is a specific steeping vessel, such as a yixing pot which is dedicated to a few types of tea
+type Vessel struct {
+	Name      string
+	Type      VesselType
+	Material  string
+	Volume    int
+	Dedicated []string
+}
+
+// Vessels is the inventory of steeping vessels, keyed by name
+var Vessels = map[string]Vessel{}
+
+func AddVessel(v Vessel) error {
+	v.Name = strings.TrimSpace(v.Name)
+	if v.Name == "" {
+		return errors.New("Vessel has no name")
+	}
+	if v.Volume == 0 {
+		v.Volume = v.Type.Volume()
+	}
+	Vessels[v.Name] = v
+	return nil
+}
+
+func LookupVessel(name string) (Vessel, bool) {
+	name = strings.TrimSpace(name)
+	for n, v := range Vessels {
+		if strings.EqualFold(n, name) {
+			return v, true
+		}
+	}
+	return Vessel{}, false
+}
+
+// Suits returns true if the vessel is not dedicated to any types of tea, or if the type is one of them
+func (v Vessel) Suits(teaType string) bool {
+	if len(v.Dedicated) == 0 {
+		return true
+	}
+	for _, d := range v.Dedicated {
+		if TeaTypes.IsA(teaType, d) {
+			return true
+		}
+	}
+	return false
+}
+
+type FixinCategory int
+
+const (
+	UncategorizedFixin FixinCategory = 0 + iota
+	Dairy
+	Sweetener
+	Flavoring
+)
+
+func (c FixinCategory) String() string {
+	switch c {
+	case Dairy:
+		return "Dairy"
+	case Sweetener:
+		return "Sweetener"
+	case Flavoring:
+		return "Flavoring"
+	default:
+		return ""
+	}
+}
+
+func ParseFixinCategory(s string) (FixinCategory, error) {
+	if strings.TrimSpace(s) == "" {
+		return UncategorizedFixin, nil
+	}
+	for _, c := range []FixinCategory{Dairy, Sweetener, Flavoring} {
+		if strings.EqualFold(strings.TrimSpace(s), c.String()) {
+			return c, nil
+		}
+	}
+	return UncategorizedFixin, errors.New(fmt.Sprintf("Unknown fixin category: %s", s))
+}
+
+type TeaFixin int
+
+const (
+	Milk TeaFixin = 0 + iota
+	Cream
+	HalfAndHalf
+	Sugar
+	BrownSugar
+	RawSugar
+	Honey
+	VanillaExtract
+	VanillaBean
+)
+
+type FixinDefinition struct {
+	Id       TeaFixin
+	Name     string
+	Category FixinCategory
+}
+
+// TeaFixins are the known fixins, which can be added to from the configuration or a lookup sheet
+var TeaFixins = map[TeaFixin]FixinDefinition{
+	Milk:           {Milk, "Milk", Dairy},
+	Cream:          {Cream, "Cream", Dairy},
+	HalfAndHalf:    {HalfAndHalf, "Half & half", Dairy},
+	Sugar:          {Sugar, "Sugar", Sweetener},
+	BrownSugar:     {BrownSugar, "Brown sugar", Sweetener},
+	RawSugar:       {RawSugar, "Raw sugar", Sweetener},
+	Honey:          {Honey, "Honey", Sweetener},
+	VanillaExtract: {VanillaExtract, "Vanilla extract", Flavoring},
+	VanillaBean:    {VanillaBean, "Vanilla bean", Flavoring},
+}
+
+func AddTeaFixin(def FixinDefinition) error {
+	if strings.TrimSpace(def.Name) == "" {
+		return errors.New(fmt.Sprintf("Fixin %d has no name", def.Id))
+	}
+	TeaFixins[def.Id] = def
+	return nil
+}
+
+// ParseTeaFixin accepts either the numeric code of a fixin or its name
+func ParseTeaFixin(s string) (TeaFixin, error) {
+	s = strings.TrimSpace(s)
+	if id, err := strconv.Atoi(s); err == nil {
+		return TeaFixin(id), nil
+	}
+
+	for id, def := range TeaFixins {
+		if strings.EqualFold(def.Name, s) {
+			return id, nil
+		}
+	}
+
+	return TeaFixin(0), errors.New(fmt.Sprintf("Unknown fixin: %s", s))
+}
+
+func (f TeaFixin) Category() FixinCategory {
+	return TeaFixins[f].Category
+}
+
+func (f TeaFixin) String() string {
+	return TeaFixins[f].Name
+}
+
+type VesselUse struct {
+	Vessel  Vessel
+	Entries int
+	Average float64
+	Types   map[string]int
+	Misuses []Entry
+}
+
+// VesselUse reports how each vessel was used in the entries of the filtered teas. Entries which do not name a
+// vessel from the inventory are reported under their type of vessel.
+func (d *TeaDb) VesselUse(filter *Filter) (map[string]VesselUse, error) {
+	log, err := d.TeasLog(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]int)
+	use := make(map[string]VesselUse)
+	for _, entry := range log {
+		name := entry.VesselName()
+
+		u, ok := use[name]
+		if !ok {
+			if u.Vessel, ok = LookupVessel(entry.Vessel); !ok {
+				u.Vessel = Vessel{Name: name, Type: entry.SteepingVessel, Volume: entry.SteepingVessel.Volume()}
+			}
+			u.Types = make(map[string]int)
+		}
+
+		tea := d.teas[entry.Tea]
+		u.Entries++
+		u.Types[TeaTypes.Canonical(tea.Type)]++
+		totals[name] += entry.Rating
+		if !u.Vessel.Suits(tea.Type) {
+			u.Misuses = append(u.Misuses, entry)
+		}
+
+		use[name] = u
+	}
+
+	for name, u := range use {
+		u.Average = float64(totals[name]) / float64(u.Entries)
+		use[name] = u
+	}
+
+	return use, nil
+}
@@ -0,0 +1,193 @@
+package hgtealib
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseVesselType(t *testing.T) {
+	tests := map[string]VesselType{
+		"8":             Gaiwan,
+		"gaiwan":        Gaiwan,
+		" French Press": FrenchPress,
+		"":              FrenchPress,
+		"42":            VesselType(42),
+	}
+
+	for s, expected := range tests {
+		v, err := ParseVesselType(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if v != expected {
+			t.Errorf("Expected '%s' to be parsed as %d but found %d", s, expected, v)
+		}
+	}
+
+	if _, err := ParseVesselType("Samovar"); err == nil {
+		t.Error("Incorrectly parsed an unknown vessel type")
+	}
+}
+
+func TestAddVesselType(t *testing.T) {
+	defer delete(VesselTypes, VesselType(100))
+
+	if err := AddVesselType(VesselTypeDefinition{Id: 100, Name: "Kyusu", Volume: 250}); err != nil {
+		t.Fatal(err)
+	}
+
+	if v, err := ParseVesselType("kyusu"); err != nil || v != VesselType(100) {
+		t.Errorf("Could not parse an added vessel type: %d, %v", v, err)
+	}
+
+	if VesselType(100).String() != "Kyusu" || VesselType(100).Volume() != 250 {
+		t.Errorf("Unexpected definition of an added vessel type: %+v", VesselTypes[100])
+	}
+
+	if err := AddVesselType(VesselTypeDefinition{Id: 101}); err == nil {
+		t.Error("Was able to add a vessel type without a name")
+	}
+}
+
+func TestParseTeaFixin(t *testing.T) {
+	tests := map[string]TeaFixin{
+		"6":           Honey,
+		"honey":       Honey,
+		"Half & half": HalfAndHalf,
+	}
+
+	for s, expected := range tests {
+		f, err := ParseTeaFixin(s)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if f != expected {
+			t.Errorf("Expected '%s' to be parsed as %d but found %d", s, expected, f)
+		}
+	}
+
+	if _, err := ParseTeaFixin("Lemon"); err == nil {
+		t.Error("Incorrectly parsed an unknown fixin")
+	}
+}
+
+func TestAddTeaFixin(t *testing.T) {
+	defer delete(TeaFixins, TeaFixin(100))
+
+	if err := AddTeaFixin(FixinDefinition{Id: 100, Name: "Oat milk", Category: Dairy}); err != nil {
+		t.Fatal(err)
+	}
+
+	if f, err := ParseTeaFixin("OAT MILK"); err != nil || f != TeaFixin(100) {
+		t.Errorf("Could not parse an added fixin: %d, %v", f, err)
+	}
+
+	if TeaFixin(100).Category() != Dairy {
+		t.Errorf("Expected an added fixin to be dairy but found %s", TeaFixin(100).Category())
+	}
+
+	if err := AddTeaFixin(FixinDefinition{Id: 101}); err == nil {
+		t.Error("Was able to add a fixin without a name")
+	}
+}
+
+func TestFixinCategory(t *testing.T) {
+	if Milk.Category() != Dairy || Honey.Category() != Sweetener || VanillaBean.Category() != Flavoring {
+		t.Error("Default fixins do not have the expected categories")
+	}
+
+	if c, err := ParseFixinCategory("sweetener"); err != nil || c != Sweetener {
+		t.Errorf("Could not parse the sweetener category: %s, %v", c, err)
+	}
+
+	if c, err := ParseFixinCategory(""); err != nil || c != UncategorizedFixin {
+		t.Errorf("Unexpected category from an empty value: %s, %v", c, err)
+	}
+
+	if _, err := ParseFixinCategory("Spice"); err == nil {
+		t.Error("Incorrectly parsed an unknown fixin category")
+	}
+}
+
+func TestAddVessel(t *testing.T) {
+	defer delete(Vessels, "Zhuni pot")
+
+	if err := AddVessel(Vessel{Name: " Zhuni pot ", Type: ShipiaoYixing}); err != nil {
+		t.Fatal(err)
+	}
+
+	v, ok := LookupVessel("zhuni POT")
+	if !ok {
+		t.Fatal("Could not look up an added vessel")
+	}
+
+	if v.Volume != ShipiaoYixing.Volume() {
+		t.Errorf("Expected vessel to default to the volume of its type but found %d", v.Volume)
+	}
+
+	if _, ok := LookupVessel("Kyusu"); ok {
+		t.Error("Looked up a vessel which is not in the inventory")
+	}
+
+	if err := AddVessel(Vessel{Type: Gaiwan}); err == nil {
+		t.Error("Was able to add a vessel without a name")
+	}
+}
+
+func TestVesselSuits(t *testing.T) {
+	if !(Vessel{Name: "Gaiwan"}).Suits("Green") {
+		t.Error("Vessel without dedicated types did not suit a tea")
+	}
+
+	v := Vessel{Name: "Pot", Dedicated: []string{"Oolong", "Shou Pu-erh"}}
+	for _, teaType := range []string{"Oolong", "Dan Cong", "shou"} {
+		if !v.Suits(teaType) {
+			t.Errorf("Vessel did not suit a dedicated type: %s", teaType)
+		}
+	}
+
+	if v.Suits("Green") {
+		t.Error("Vessel suited a type it is not dedicated to")
+	}
+}
+
+func TestTeaDbVesselUse(t *testing.T) {
+	defer delete(Vessels, "Zhuni pot")
+	if err := AddVessel(Vessel{Name: "Zhuni pot", Type: ShipiaoYixing, Dedicated: []string{"Oolong"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	db, err := newTeaDb([]*Tea{
+		{Id: 1, Type: "Oolong"},
+		{Id: 2, Type: "Green"},
+	}, []*Entry{
+		{Tea: 1, DateTime: time.Unix(1, 0), Rating: 4, Vessel: "Zhuni pot", SteepingVessel: ShipiaoYixing},
+		{Tea: 2, DateTime: time.Unix(2, 0), Rating: 1, Vessel: "Zhuni pot", SteepingVessel: ShipiaoYixing},
+		{Tea: 2, DateTime: time.Unix(3, 0), Rating: 3, SteepingVessel: Gaiwan},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	use, err := db.VesselUse(NewFilter())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(use) != 2 {
+		t.Fatalf("Expected the use of 2 vessels but found %d", len(use))
+	}
+
+	pot := use["Zhuni pot"]
+	if pot.Entries != 2 || pot.Average != 2.5 || pot.Types["Oolong"] != 1 || pot.Types["Green"] != 1 {
+		t.Errorf("Unexpected use of the pot: %+v", pot)
+	}
+
+	if len(pot.Misuses) != 1 || pot.Misuses[0].Tea != 2 {
+		t.Errorf("Expected the pot to be misused for the green tea: %+v", pot.Misuses)
+	}
+
+	if gaiwan := use[Gaiwan.String()]; gaiwan.Entries != 1 || gaiwan.Vessel.Type != Gaiwan || len(gaiwan.Misuses) != 0 {
+		t.Errorf("Unexpected use of the gaiwan: %+v", gaiwan)
+	}
+}